@@ -0,0 +1,488 @@
+/*
+
+elementary functions for big.Float.
+
+math/big only has the arithmetic and Sqrt, so the values of the
+elementary functions at the seed are computed here, to any precision.
+each one reduces its argument until a Taylor series converges quickly,
+sums the series with bigGuard extra bits, and rounds the result back
+to the requested precision:
+
+	exp    halving, exp(x) = exp(x / 2^s)^(2^s)
+	log    Halley's method on exp, after splitting off the exponent
+	sin    reduction modulo π/2, with π from Machin's formula
+	atan   halving, atan(x) = 2*atan(x / (1 + sqrt(1 + x^2)))
+
+the rest are built from those, in whichever form avoids cancellation.
+
+big.Float has no NaN, so none of these are defined outside of their
+domain, and the callers check the domain first. it does have infinities,
+and exp overflows to one once its result passes the largest exponent of
+a big.Float.
+
+*/
+
+package gdual
+
+import (
+	"math"
+	"math/big"
+	"sync"
+)
+
+// the extra bits every function is computed with, before rounding
+const bigGuard = 64
+
+func newBigFloat(prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec)
+}
+
+func bigInt(n int, prec uint) *big.Float {
+	return newBigFloat(prec).SetInt64(int64(n))
+}
+
+// the binary exponent of x, so that 2^(e-1) <= |x| < 2^e
+func bigExponent(x *big.Float) int {
+	return x.MantExp(nil)
+}
+
+// whether term is too small to change sum at wp bits
+func negligible(term, sum *big.Float, wp uint) bool {
+	if term.Sign() == 0 {
+		return true
+	}
+
+	if sum.Sign() == 0 {
+		return false
+	}
+
+	return bigExponent(term) < bigExponent(sum)-int(wp)
+}
+
+/* constants */
+
+// the most precise value computed so far, rounded down on every request
+type bigConstant struct {
+	mu  sync.Mutex
+	val *big.Float
+	fn  func(wp uint) *big.Float
+}
+
+func (c *bigConstant) get(prec uint) *big.Float {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.val == nil || c.val.Prec() < prec+bigGuard {
+		c.val = c.fn(prec + bigGuard)
+	}
+
+	return newBigFloat(prec).Set(c.val)
+}
+
+// π = 16*atan(1/5) - 4*atan(1/239)
+var bigPiConstant = &bigConstant{fn: func(wp uint) *big.Float {
+	a := atanSeries(newBigFloat(wp).Quo(bigInt(1, wp), bigInt(5, wp)), wp)
+	b := atanSeries(newBigFloat(wp).Quo(bigInt(1, wp), bigInt(239, wp)), wp)
+
+	a.Mul(a, bigInt(16, wp))
+	b.Mul(b, bigInt(4, wp))
+
+	return a.Sub(a, b)
+}}
+
+var bigLn2Constant = &bigConstant{fn: func(wp uint) *big.Float {
+	return logNewton(bigInt(2, wp), math.Ln2, wp)
+}}
+
+func bigPi(prec uint) *big.Float {
+	return bigPiConstant.get(prec)
+}
+
+func bigLn2(prec uint) *big.Float {
+	return bigLn2Constant.get(prec)
+}
+
+/* exponential and logarithm */
+
+func bigExp(x *big.Float, prec uint) *big.Float {
+	if x.Sign() == 0 {
+		return bigInt(1, prec)
+	}
+
+	// exp(x) overflows (or underflows) once |x| > ln(2) * MaxExp, which
+	// is below 2^31, and scaling x any further would only waste precision
+	if bigExponent(x) > 31 {
+		if x.Sign() < 0 {
+			return newBigFloat(prec)
+		}
+
+		return newBigFloat(prec).SetInf(false)
+	}
+
+	// scale x by 2^-s, so that |x| < 2^-8, and square the result s times
+	s := bigExponent(x) + 8
+	if s < 0 {
+		s = 0
+	}
+	wp := prec + bigGuard + uint(s)
+
+	r := newBigFloat(wp).SetMantExp(x, -s)
+	sum := bigInt(1, wp)
+	term := bigInt(1, wp)
+	for n := 1; ; n++ {
+		term.Mul(term, r)
+		term.Quo(term, bigInt(n, wp))
+		sum.Add(sum, term)
+
+		if negligible(term, sum, wp) {
+			break
+		}
+	}
+
+	for i := 0; i < s; i++ {
+		sum.Mul(sum, sum)
+	}
+
+	return newBigFloat(prec).Set(sum)
+}
+
+// solves exp(y) = x, starting from the float64 guess y0. Halley's
+// iteration y += 2*(x - exp(y)) / (x + exp(y)) triples the correct
+// bits every step.
+func logNewton(x *big.Float, y0 float64, wp uint) *big.Float {
+	y := newBigFloat(wp).SetFloat64(y0)
+	num := newBigFloat(wp)
+	den := newBigFloat(wp)
+	for i := 0; i < 64; i++ {
+		e := bigExp(y, wp)
+		num.Sub(x, e)
+		den.Add(x, e)
+		num.Quo(num, den)
+		num.Mul(num, bigInt(2, wp))
+		y.Add(y, num)
+
+		if negligible(num, y, wp-8) {
+			break
+		}
+	}
+
+	return y
+}
+
+// log(x), for x > 0
+func bigLog(x *big.Float, prec uint) *big.Float {
+	// x = m * 2^e with m in [1/sqrt(2), sqrt(2)), so that x near 1 isn't
+	// split into two big terms that cancel
+	m := newBigFloat(x.Prec())
+	e := x.MantExp(m)
+	if m.Cmp(big.NewFloat(math.Sqrt2/2)) < 0 {
+		m.SetMantExp(m, 1)
+		e--
+	}
+
+	if e == 0 && m.Cmp(big.NewFloat(1)) == 0 {
+		return newBigFloat(prec)
+	}
+
+	// log(m) is about m - 1, which loses bits when it's small
+	wp := prec + bigGuard
+	delta := newBigFloat(wp).Sub(m, big.NewFloat(1))
+	if exp := bigExponent(delta); delta.Sign() != 0 && exp < 0 {
+		wp += uint(-exp)
+	}
+
+	mf, _ := m.Float64()
+	y := logNewton(newBigFloat(wp).Set(m), math.Log(mf), wp)
+	if e != 0 {
+		ln2 := bigLn2(wp)
+		y.Add(y, ln2.Mul(ln2, bigInt(e, wp)))
+	}
+
+	return newBigFloat(prec).Set(y)
+}
+
+func bigSqrt(x *big.Float, prec uint) *big.Float {
+	return newBigFloat(prec).Sqrt(x)
+}
+
+/* trigonometric functions */
+
+// sin and cos of x, for |x| <= π/4, with wp bits
+func sinCosSeries(x *big.Float, wp uint) (*big.Float, *big.Float) {
+	x2 := newBigFloat(wp).Mul(x, x)
+
+	sin := newBigFloat(wp).Set(x)
+	cos := bigInt(1, wp)
+	sinTerm := newBigFloat(wp).Set(x)
+	cosTerm := bigInt(1, wp)
+	for n := 1; ; n++ {
+		// x^(2n+1) / (2n+1)! and x^(2n) / (2n)!
+		sinTerm.Mul(sinTerm, x2)
+		sinTerm.Quo(sinTerm, bigInt(2*n*(2*n+1), wp))
+		sinTerm.Neg(sinTerm)
+		cosTerm.Mul(cosTerm, x2)
+		cosTerm.Quo(cosTerm, bigInt((2*n-1)*(2*n), wp))
+		cosTerm.Neg(cosTerm)
+
+		sin.Add(sin, sinTerm)
+		cos.Add(cos, cosTerm)
+
+		if negligible(sinTerm, sin, wp) && negligible(cosTerm, cos, wp) {
+			break
+		}
+	}
+
+	return sin, cos
+}
+
+func bigSinCos(x *big.Float, prec uint) (*big.Float, *big.Float) {
+	if x.Sign() == 0 {
+		return newBigFloat(prec), bigInt(1, prec)
+	}
+
+	// the reduction needs as many more bits as x has before the point
+	wp := prec + bigGuard
+	if exp := bigExponent(x); exp > 0 {
+		wp += uint(exp)
+	}
+
+	// x = k*π/2 + r, with |r| <= π/4
+	halfPi := bigPi(wp)
+	halfPi.SetMantExp(halfPi, -1)
+
+	q := newBigFloat(wp).Quo(x, halfPi)
+	q.Add(q, big.NewFloat(0.5))
+	k, _ := q.Int(nil)
+	if q.Sign() < 0 && !q.IsInt() {
+		k.Sub(k, big.NewInt(1))
+	}
+
+	r := newBigFloat(wp).SetInt(k)
+	r.Mul(r, halfPi)
+	r.Sub(x, r)
+
+	sin, cos := sinCosSeries(r, wp)
+	switch new(big.Int).And(k, big.NewInt(3)).Int64() {
+	case 1:
+		sin, cos = cos, sin.Neg(sin)
+	case 2:
+		sin, cos = sin.Neg(sin), cos.Neg(cos)
+	case 3:
+		sin, cos = cos.Neg(cos), sin
+	}
+
+	return newBigFloat(prec).Set(sin), newBigFloat(prec).Set(cos)
+}
+
+// Σ (-1)^n x^(2n+1) / (2n+1), for |x| < 1
+func atanSeries(x *big.Float, wp uint) *big.Float {
+	x2 := newBigFloat(wp).Mul(x, x)
+
+	sum := newBigFloat(wp).Set(x)
+	power := newBigFloat(wp).Set(x)
+	term := newBigFloat(wp)
+	for n := 1; ; n++ {
+		power.Mul(power, x2)
+		power.Neg(power)
+		term.Quo(power, bigInt(2*n+1, wp))
+		sum.Add(sum, term)
+
+		if negligible(term, sum, wp) {
+			break
+		}
+	}
+
+	return sum
+}
+
+func bigAtan(x *big.Float, prec uint) *big.Float {
+	if x.Sign() == 0 {
+		return newBigFloat(prec)
+	}
+
+	wp := prec + bigGuard
+
+	// atan(x) = ±π/2 - atan(1/x) for |x| > 1
+	if new(big.Float).Abs(x).Cmp(big.NewFloat(1)) > 0 {
+		inv := newBigFloat(wp).Quo(bigInt(1, wp), x)
+		out := bigPi(wp)
+		out.SetMantExp(out, -1)
+		if x.Sign() < 0 {
+			out.Neg(out)
+		}
+		out.Sub(out, bigAtan(inv, wp))
+
+		return newBigFloat(prec).Set(out)
+	}
+
+	// halve the angle until the series converges quickly
+	r := newBigFloat(wp).Set(x)
+	halvings := 0
+	den := newBigFloat(wp)
+	for bigExponent(r) > -8 {
+		den.Mul(r, r)
+		den.Add(den, big.NewFloat(1))
+		den.Sqrt(den)
+		den.Add(den, big.NewFloat(1))
+		r.Quo(r, den)
+		halvings++
+	}
+
+	out := atanSeries(r, wp)
+	out.SetMantExp(out, halvings)
+
+	return newBigFloat(prec).Set(out)
+}
+
+// atan2(y, x), for y and x not both zero
+func bigAtan2(y, x *big.Float, prec uint) *big.Float {
+	wp := prec + bigGuard
+
+	if x.Sign() == 0 {
+		out := bigPi(wp)
+		out.SetMantExp(out, -1)
+		if y.Sign() < 0 {
+			out.Neg(out)
+		}
+
+		return newBigFloat(prec).Set(out)
+	}
+
+	out := bigAtan(newBigFloat(wp).Quo(y, x), wp)
+	if x.Sign() < 0 {
+		if y.Signbit() {
+			out.Sub(out, bigPi(wp))
+		} else {
+			out.Add(out, bigPi(wp))
+		}
+	}
+
+	return newBigFloat(prec).Set(out)
+}
+
+// asin(x) = atan(x / sqrt((1 - x)(1 + x))), for |x| < 1
+func bigAsin(x *big.Float, prec uint) *big.Float {
+	wp := prec + bigGuard
+	one := big.NewFloat(1)
+
+	den := newBigFloat(wp).Sub(one, x)
+	den.Mul(den, newBigFloat(wp).Add(one, x))
+	den.Sqrt(den)
+
+	return bigAtan(newBigFloat(wp).Quo(x, den), prec)
+}
+
+// acos(x) = 2*atan(sqrt((1 - x) / (1 + x))), for |x| < 1
+func bigAcos(x *big.Float, prec uint) *big.Float {
+	wp := prec + bigGuard
+	one := big.NewFloat(1)
+
+	r := newBigFloat(wp).Sub(one, x)
+	r.Quo(r, newBigFloat(wp).Add(one, x))
+	r.Sqrt(r)
+
+	out := bigAtan(r, wp)
+	out.SetMantExp(out, 1)
+
+	return newBigFloat(prec).Set(out)
+}
+
+/* hyperbolic functions */
+
+// extra bits for functions that are about x near zero, but are
+// computed from terms of about 1
+func smallGuard(x *big.Float) uint {
+	if exp := bigExponent(x); x.Sign() != 0 && exp < 0 {
+		return uint(-exp)
+	}
+
+	return 0
+}
+
+func bigSinhCosh(x *big.Float, prec uint) (*big.Float, *big.Float) {
+	wp := prec + bigGuard + smallGuard(x)
+
+	e := bigExp(x, wp)
+	inv := newBigFloat(wp).Quo(bigInt(1, wp), e)
+
+	sinh := newBigFloat(wp).Sub(e, inv)
+	cosh := newBigFloat(wp).Add(e, inv)
+	sinh.SetMantExp(sinh, -1)
+	cosh.SetMantExp(cosh, -1)
+
+	return newBigFloat(prec).Set(sinh), newBigFloat(prec).Set(cosh)
+}
+
+// asinh(x) = sign(x) * log(|x| + sqrt(x^2 + 1))
+func bigAsinh(x *big.Float, prec uint) *big.Float {
+	wp := prec + bigGuard + smallGuard(x)
+
+	ax := newBigFloat(wp).Abs(x)
+	r := newBigFloat(wp).Mul(ax, ax)
+	r.Add(r, big.NewFloat(1))
+	r.Sqrt(r)
+	r.Add(r, ax)
+
+	out := bigLog(r, wp)
+	if x.Sign() < 0 {
+		out.Neg(out)
+	}
+
+	return newBigFloat(prec).Set(out)
+}
+
+// acosh(x) = log(x + sqrt(x^2 - 1)), for x >= 1
+func bigAcosh(x *big.Float, prec uint) *big.Float {
+	wp := prec + bigGuard
+
+	r := newBigFloat(wp).Mul(x, x)
+	r.Sub(r, big.NewFloat(1))
+	r.Sqrt(r)
+	r.Add(r, x)
+
+	return bigLog(r, prec)
+}
+
+// atanh(x) = log((1 + x) / (1 - x)) / 2, for |x| < 1
+func bigAtanh(x *big.Float, prec uint) *big.Float {
+	wp := prec + bigGuard + smallGuard(x)
+	one := big.NewFloat(1)
+
+	r := newBigFloat(wp).Add(one, x)
+	r.Quo(r, newBigFloat(wp).Sub(one, x))
+
+	out := bigLog(r, wp)
+	out.SetMantExp(out, -1)
+
+	return newBigFloat(prec).Set(out)
+}
+
+/* powers */
+
+// x^p, for x > 0, or any x when p is an integer
+func bigPow(x, p *big.Float, prec uint) *big.Float {
+	if x.Sign() == 0 {
+		if p.Sign() == 0 {
+			return bigInt(1, prec)
+		}
+
+		return newBigFloat(prec)
+	}
+
+	wp := prec + bigGuard
+	ax := newBigFloat(wp).Abs(x)
+
+	out := bigLog(ax, wp)
+	out.Mul(out, p)
+	out = bigExp(out, wp)
+
+	// a negative base only has a real power for an integer exponent
+	if x.Sign() < 0 {
+		n, _ := p.Int(nil)
+		if n.Bit(0) == 1 {
+			out.Neg(out)
+		}
+	}
+
+	return newBigFloat(prec).Set(out)
+}
@@ -0,0 +1,127 @@
+package gdual
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// π to 100 decimal places
+const piDigits = "3.1415926535897932384626433832795028841971693993751058209749445923078164062862089986280348253421170679"
+
+func TestBigConstants(t *testing.T) {
+	prec := uint(340)
+
+	expected, _, _ := big.ParseFloat(piDigits, 10, prec, big.ToNearestEven)
+	have := bigPi(prec)
+
+	diff := new(big.Float).Sub(have, expected)
+	if diff.Sign() != 0 && bigExponent(diff) > -320 {
+		t.Errorf("value mismatch on pi: have %s want %s", have.Text('g', 100), piDigits)
+	}
+
+	// the cached value is rounded down for lower precisions
+	if low, _ := bigPi(53).Float64(); low != math.Pi {
+		t.Errorf("value mismatch on pi at 53 bits: have %v want %v", low, math.Pi)
+	}
+}
+
+func TestBigFunctions(t *testing.T) {
+	prec := uint(53)
+	inputs := []float64{-7.5, -1.2, -0.3, -1e-9, 1e-9, 0.25, 0.9, 1.7, 12.0, 1e5}
+
+	tests := []struct {
+		name     string
+		have     func(x *big.Float) *big.Float
+		expected func(x float64) float64
+		domain   func(x float64) bool
+	}{
+		{"exp", func(x *big.Float) *big.Float {
+			return bigExp(x, prec)
+		}, math.Exp, func(x float64) bool { return x < 700 }},
+		{"log", func(x *big.Float) *big.Float {
+			return bigLog(x, prec)
+		}, math.Log, func(x float64) bool { return x > 0 }},
+		{"sin", func(x *big.Float) *big.Float {
+			s, _ := bigSinCos(x, prec)
+			return s
+		}, math.Sin, func(x float64) bool { return true }},
+		{"cos", func(x *big.Float) *big.Float {
+			_, c := bigSinCos(x, prec)
+			return c
+		}, math.Cos, func(x float64) bool { return true }},
+		{"atan", func(x *big.Float) *big.Float {
+			return bigAtan(x, prec)
+		}, math.Atan, func(x float64) bool { return true }},
+		{"asin", func(x *big.Float) *big.Float {
+			return bigAsin(x, prec)
+		}, math.Asin, func(x float64) bool { return math.Abs(x) < 1 }},
+		{"acos", func(x *big.Float) *big.Float {
+			return bigAcos(x, prec)
+		}, math.Acos, func(x float64) bool { return math.Abs(x) < 1 }},
+		{"sinh", func(x *big.Float) *big.Float {
+			s, _ := bigSinhCosh(x, prec)
+			return s
+		}, math.Sinh, func(x float64) bool { return math.Abs(x) < 700 }},
+		{"asinh", func(x *big.Float) *big.Float {
+			return bigAsinh(x, prec)
+		}, math.Asinh, func(x float64) bool { return true }},
+		{"acosh", func(x *big.Float) *big.Float {
+			return bigAcosh(x, prec)
+		}, math.Acosh, func(x float64) bool { return x >= 1 }},
+		{"atanh", func(x *big.Float) *big.Float {
+			return bigAtanh(x, prec)
+		}, math.Atanh, func(x float64) bool { return math.Abs(x) < 1 }},
+	}
+
+	for i, tt := range tests {
+		for _, inp := range inputs {
+			if !tt.domain(inp) {
+				continue
+			}
+
+			have, _ := tt.have(big.NewFloat(inp)).Float64()
+			expected := tt.expected(inp)
+			if math.Abs(have-expected) > 4e-16*math.Abs(expected) {
+				t.Errorf("value mismatch on %s test %d (input %g): have %v want %v",
+					tt.name, i, inp, have, expected)
+			}
+		}
+	}
+}
+
+func TestBigIdentities(t *testing.T) {
+	prec := uint(500)
+	tolerance := -480
+
+	for _, inp := range []string{"0.1", "-2.5", "3.75", "100.125"} {
+		x, _, _ := big.ParseFloat(inp, 10, prec, big.ToNearestEven)
+
+		// exp(log(|x|)) = |x|
+		ax := new(big.Float).Abs(x)
+		diff := newBigFloat(prec).Sub(bigExp(bigLog(ax, prec), prec), ax)
+		diff.Quo(diff, ax)
+		if diff.Sign() != 0 && bigExponent(diff) > tolerance {
+			t.Errorf("value mismatch on exp(log(%s)): off by %s", inp, diff.Text('g', 5))
+		}
+
+		// sin^2 + cos^2 = 1
+		s, c := bigSinCos(x, prec)
+		s.Mul(s, s)
+		c.Mul(c, c)
+		diff = newBigFloat(prec).Add(s, c)
+		diff.Sub(diff, big.NewFloat(1))
+		if diff.Sign() != 0 && bigExponent(diff) > tolerance {
+			t.Errorf("value mismatch on sin^2 + cos^2 (%s): off by %s", inp, diff.Text('g', 5))
+		}
+
+		// tan(atan(x)) = x
+		s, c = bigSinCos(bigAtan(x, prec), prec)
+		diff = newBigFloat(prec).Quo(s, c)
+		diff.Sub(diff, x)
+		diff.Quo(diff, x)
+		if diff.Sign() != 0 && bigExponent(diff) > tolerance {
+			t.Errorf("value mismatch on tan(atan(%s)): off by %s", inp, diff.Text('g', 5))
+		}
+	}
+}
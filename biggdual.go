@@ -0,0 +1,310 @@
+/*
+
+arbitrary precision generalized dual numbers.
+
+BigGDual is GDual with big.Float coefficients of a configurable
+precision, for high orders where float64 cancellation destroys the
+coefficients, or as a reference to check the float64 results against.
+
+where a float64 GDual would fill its coefficients with NaN or infinities
+(division by zero, a seed outside the domain of a function, or overflow),
+a BigGDual keeps the error instead. every result computed from it carries the same error,
+and its coefficients are all zero, so a whole expression can be checked
+once at the end with Err.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type BigGDual struct {
+	mat      *BigUpperTriToeplitz
+	variable bool
+	err      error
+}
+
+// NewBigGDual creates a BigGDual with coefficients of prec bits. the seed
+// is rounded to prec, so seeds like 0.1 should be parsed with big.ParseFloat
+// rather than converted from a float64.
+func NewBigGDual(order int, prec uint, seed *big.Float, variable bool) *BigGDual {
+	mat := NewBigUpperTriToeplitz(order, prec)
+	mat.Fill(0, seed)
+	if variable {
+		mat.Fill(1, bigInt(1, prec))
+	}
+
+	gdual := &BigGDual{
+		mat:      mat,
+		variable: variable,
+	}
+
+	return gdual
+}
+
+func importBigGDual(mat *BigUpperTriToeplitz, variable bool, err error) *BigGDual {
+	if err != nil {
+		mat = NewBigUpperTriToeplitz(mat.order, mat.prec)
+	}
+
+	gdual := &BigGDual{
+		mat:      mat,
+		variable: variable,
+		err:      err,
+	}
+
+	return gdual
+}
+
+// applies a function of the matrix, unless g already carries an error
+func (g *BigGDual) apply(fn func(*BigUpperTriToeplitz) (*BigUpperTriToeplitz, error)) *BigGDual {
+	if g.err != nil {
+		return g
+	}
+
+	mat, err := guard(func() (*BigUpperTriToeplitz, error) {
+		return fn(g.mat)
+	})
+	if err != nil {
+		return importBigGDual(g.mat, g.variable, err)
+	}
+
+	return importBigGDual(mat, g.variable, nil)
+}
+
+// applies a function of both matrices, keeping the first error of either
+func (g *BigGDual) combine(inp *BigGDual, fn func(a, b *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error)) *BigGDual {
+	variable := g.variable || inp.variable
+	if g.err != nil {
+		return importBigGDual(g.mat, variable, g.err)
+	}
+
+	if inp.err != nil {
+		return importBigGDual(g.mat, variable, inp.err)
+	}
+
+	mat, err := guard(func() (*BigUpperTriToeplitz, error) {
+		return fn(g.mat, inp.mat)
+	})
+	if err != nil {
+		return importBigGDual(g.mat, variable, err)
+	}
+
+	return importBigGDual(mat, variable, nil)
+}
+
+// guard runs fn, turning the big.ErrNaN panics of big.Float into an error.
+// NaN only comes from a coefficient that overflowed to an infinity first,
+// so both are reported as ErrOverflow.
+func guard(fn func() (*BigUpperTriToeplitz, error)) (mat *BigUpperTriToeplitz, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			nan, ok := r.(big.ErrNaN)
+			if !ok {
+				panic(r)
+			}
+
+			mat, err = nil, fmt.Errorf("%w: %s", ErrOverflow, nan.Error())
+		}
+	}()
+
+	mat, err = fn()
+	if err != nil {
+		return nil, err
+	}
+
+	return mat, mat.overflow()
+}
+
+// adapts functions that can't fail to apply
+func noError(fn func(*BigUpperTriToeplitz) *BigUpperTriToeplitz) func(*BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+	return func(m *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+		return fn(m), nil
+	}
+}
+
+/* accessors */
+
+// Err returns the first error in the computation of g, if any
+func (g *BigGDual) Err() error {
+	return g.err
+}
+
+func (g *BigGDual) Order() int {
+	return g.mat.order
+}
+
+func (g *BigGDual) Prec() uint {
+	return g.mat.prec
+}
+
+// Value returns f(x0), the value of the function at the seed
+func (g *BigGDual) Value() *big.Float {
+	return g.mat.Coefficient(0)
+}
+
+// Coefficient returns the k-th Taylor coefficient, f^(k)(x0) / k!
+func (g *BigGDual) Coefficient(k int) (*big.Float, error) {
+	if k < 0 || k >= g.mat.order {
+		return nil, fmt.Errorf("%w: coefficient %d of order %d", ErrIndexOutOfRange, k, g.mat.order)
+	}
+
+	return g.mat.Coefficient(k), nil
+}
+
+// Coefficients returns a copy of every Taylor coefficient
+func (g *BigGDual) Coefficients() []*big.Float {
+	coefs := make([]*big.Float, g.mat.order)
+	for k := range coefs {
+		coefs[k] = g.mat.Coefficient(k)
+	}
+
+	return coefs
+}
+
+// Derivative returns the k-th derivative, f^(k)(x0)
+func (g *BigGDual) Derivative(k int) (*big.Float, error) {
+	coef, err := g.Coefficient(k)
+	if err != nil {
+		return nil, err
+	}
+
+	factorial := new(big.Int).MulRange(1, int64(k))
+	coef.Mul(coef, newBigFloat(g.mat.prec).SetInt(factorial))
+
+	return coef, nil
+}
+
+// Derivatives returns every derivative, from f(x0) up to f^(order-1)(x0)
+func (g *BigGDual) Derivatives() []*big.Float {
+	derivs := g.Coefficients()
+
+	factorial := big.NewInt(1)
+	scale := newBigFloat(g.mat.prec)
+	for k := range derivs {
+		if k > 1 {
+			factorial.Mul(factorial, big.NewInt(int64(k)))
+		}
+		derivs[k].Mul(derivs[k], scale.SetInt(factorial))
+	}
+
+	return derivs
+}
+
+/* arithmetic */
+
+func (g *BigGDual) Add(inp *BigGDual) *BigGDual {
+	return g.combine(inp, func(a, b *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+		return a.Add(b), nil
+	})
+}
+
+func (g *BigGDual) Sub(inp *BigGDual) *BigGDual {
+	return g.combine(inp, func(a, b *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+		return a.Sub(b), nil
+	})
+}
+
+func (g *BigGDual) Mul(inp *BigGDual) *BigGDual {
+	return g.combine(inp, func(a, b *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+		return a.Mul(b), nil
+	})
+}
+
+func (g *BigGDual) Div(inp *BigGDual) *BigGDual {
+	return g.combine(inp, (*BigUpperTriToeplitz).Div)
+}
+
+func (g *BigGDual) Inv() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Inv)
+}
+
+func (g *BigGDual) Pow(n int) *BigGDual {
+	return g.apply(func(m *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+		return m.Pow(n)
+	})
+}
+
+/* elementary functions */
+
+func (g *BigGDual) Exp() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Exp)
+}
+
+func (g *BigGDual) Log() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Log)
+}
+
+func (g *BigGDual) Sqrt() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Sqrt)
+}
+
+func (g *BigGDual) Sin() *BigGDual {
+	return g.apply(noError((*BigUpperTriToeplitz).Sin))
+}
+
+func (g *BigGDual) Cos() *BigGDual {
+	return g.apply(noError((*BigUpperTriToeplitz).Cos))
+}
+
+func (g *BigGDual) Tan() *BigGDual {
+	return g.apply(noError((*BigUpperTriToeplitz).Tan))
+}
+
+func (g *BigGDual) Sinh() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Sinh)
+}
+
+func (g *BigGDual) Cosh() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Cosh)
+}
+
+func (g *BigGDual) Tanh() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Tanh)
+}
+
+/* inverse functions */
+
+func (g *BigGDual) Asin() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Asin)
+}
+
+func (g *BigGDual) Acos() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Acos)
+}
+
+func (g *BigGDual) Atan() *BigGDual {
+	return g.apply(noError((*BigUpperTriToeplitz).Atan))
+}
+
+// Atan2 returns atan(g / inp), using the signs of both to pick the quadrant
+func (g *BigGDual) Atan2(inp *BigGDual) *BigGDual {
+	return g.combine(inp, (*BigUpperTriToeplitz).Atan2)
+}
+
+func (g *BigGDual) Asinh() *BigGDual {
+	return g.apply(noError((*BigUpperTriToeplitz).Asinh))
+}
+
+func (g *BigGDual) Acosh() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Acosh)
+}
+
+func (g *BigGDual) Atanh() *BigGDual {
+	return g.apply((*BigUpperTriToeplitz).Atanh)
+}
+
+/* powers */
+
+func (g *BigGDual) PowReal(p *big.Float) *BigGDual {
+	return g.apply(func(m *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+		return m.PowReal(p)
+	})
+}
+
+func (g *BigGDual) PowGDual(e *BigGDual) *BigGDual {
+	return g.combine(e, (*BigUpperTriToeplitz).PowBig)
+}
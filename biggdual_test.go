@@ -0,0 +1,266 @@
+package gdual
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestBigOracle(t *testing.T) {
+	order := 15
+	prec := uint(200)
+	inp := 0.3
+
+	// the float64 functions, checked against the big.Float ones
+	x := NewGDual(order, inp, true)
+	bx := NewBigGDual(order, prec, big.NewFloat(inp), true)
+	two := NewGDual(order, 2.0, false)
+	btwo := NewBigGDual(order, prec, big.NewFloat(2.0), false)
+
+	tests := []struct {
+		name     string
+		have     *GDual
+		expected *BigGDual
+	}{
+		{"exp", x.Exp(), bx.Exp()},
+		{"log", x.Log(), bx.Log()},
+		{"sqrt", x.Sqrt(), bx.Sqrt()},
+		{"sin", x.Sin(), bx.Sin()},
+		{"cos", x.Cos(), bx.Cos()},
+		{"tan", x.Tan(), bx.Tan()},
+		{"sinh", x.Sinh(), bx.Sinh()},
+		{"cosh", x.Cosh(), bx.Cosh()},
+		{"tanh", x.Tanh(), bx.Tanh()},
+		{"asin", x.Asin(), bx.Asin()},
+		{"acos", x.Acos(), bx.Acos()},
+		{"atan", x.Atan(), bx.Atan()},
+		{"atan2", x.Atan2(two.Sub(x)), bx.Atan2(btwo.Sub(bx))},
+		{"asinh", x.Asinh(), bx.Asinh()},
+		{"acosh", x.Add(two).Acosh(), bx.Add(btwo).Acosh()},
+		{"atanh", x.Atanh(), bx.Atanh()},
+		{"pow x^-2.5", x.PowReal(-2.5), bx.PowReal(big.NewFloat(-2.5))},
+		{"pow x^x", x.PowGDual(x), bx.PowGDual(bx)},
+		{"div", x.Sin().Div(two.Add(x.Pow(3))), bx.Sin().Div(btwo.Add(bx.Pow(3)))},
+	}
+
+	for i, tt := range tests {
+		if err := tt.expected.Err(); err != nil {
+			t.Errorf("failed on %s oracle test %d: %v", tt.name, i, err)
+			continue
+		}
+
+		for k := 0; k < order; k++ {
+			have := tt.have.mat.get(k)
+			expected, _ := tt.expected.mat.get(k).Float64()
+			if math.Abs(have-expected) > 1e-12*math.Max(1, math.Abs(expected)) {
+				t.Errorf("value mismatch on %s oracle test %d (col %d): have %v want %v",
+					tt.name, i, k, have, expected)
+			}
+		}
+	}
+}
+
+func TestBigHighOrder(t *testing.T) {
+	order := 40
+	prec := uint(256)
+
+	// f(3.0) = 4x^2 / (1 - x)^3, as in TestComplex
+	x := NewBigGDual(order, prec, big.NewFloat(3.0), true)
+	one := NewBigGDual(order, prec, big.NewFloat(1.0), false)
+	four := NewBigGDual(order, prec, big.NewFloat(4.0), false)
+	y := x.Pow(2).Mul(four).Div(one.Sub(x).Pow(3))
+
+	// with h = x - 3, f = -(1/2) * (3 + h)^2 * (1 + h/2)^-3, where
+	// (1 + h/2)^-3 = Σ (k+1)(k+2)/2 * (-1/2)^k * h^k
+	binomial := make([]*big.Rat, order)
+	for k := range binomial {
+		coef := big.NewRat(int64((k+1)*(k+2)), 2)
+		coef.Mul(coef, new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), uint(k))))
+		if k%2 == 1 {
+			coef.Neg(coef)
+		}
+		binomial[k] = coef
+	}
+
+	square := []*big.Rat{big.NewRat(9, 1), big.NewRat(6, 1), big.NewRat(1, 1)}
+	for k := 0; k < order; k++ {
+		expected := new(big.Rat)
+		for j := 0; j < len(square) && j <= k; j++ {
+			expected.Add(expected, new(big.Rat).Mul(square[j], binomial[k-j]))
+		}
+		expected.Mul(expected, big.NewRat(-1, 2))
+
+		have, _ := y.mat.get(k).Rat(nil)
+		if have.Cmp(expected) != 0 {
+			t.Errorf("value mismatch on high order test (col %d): have %s want %s",
+				k, have.FloatString(20), expected.FloatString(20))
+		}
+	}
+}
+
+func TestBigErrors(t *testing.T) {
+	order := 5
+	prec := uint(100)
+
+	x := NewBigGDual(order, prec, big.NewFloat(-1.0), true)
+	zero := NewBigGDual(order, prec, big.NewFloat(0.0), false)
+
+	tests := []struct {
+		name     string
+		have     *BigGDual
+		expected error
+	}{
+		{"log", x.Log(), ErrDomain},
+		{"sqrt", x.Sqrt(), ErrDomain},
+		{"sqrt at zero", zero.Sqrt(), ErrDomain},
+		{"asin", x.Asin(), ErrDomain},
+		{"acosh", x.Acosh(), ErrDomain},
+		{"pow", x.PowReal(big.NewFloat(0.5)), ErrDomain},
+		{"div", x.Div(zero), ErrDivisionByZero},
+		{"inv", zero.Inv(), ErrDivisionByZero},
+		{"propagated", x.Log().Exp().Add(x).Mul(x.Sin()), ErrDomain},
+		{"propagated right", x.Add(zero.Inv()), ErrDivisionByZero},
+		{"none", x.Exp().Pow(-2).PowReal(big.NewFloat(3.0)), nil},
+	}
+
+	for i, tt := range tests {
+		if !errors.Is(tt.have.Err(), tt.expected) {
+			t.Errorf("error mismatch on %s test %d: have %v want %v",
+				tt.name, i, tt.have.Err(), tt.expected)
+		}
+	}
+
+	if _, err := x.Coefficient(order); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("error mismatch on coefficient: have %v want %v", err, ErrIndexOutOfRange)
+	}
+}
+
+func TestBigOverflow(t *testing.T) {
+	order := 4
+	prec := uint(100)
+
+	x := NewBigGDual(order, prec, big.NewFloat(1e10), true)
+	negative := NewBigGDual(order, prec, big.NewFloat(-1e10), true)
+	huge := NewBigGDual(order, prec, new(big.Float).SetMantExp(big.NewFloat(1), big.MaxExp/2+1), true)
+
+	tests := []struct {
+		name string
+		have *BigGDual
+	}{
+		{"exp", x.Exp()},
+		{"sinh", x.Sinh()},
+		{"cosh", negative.Cosh()},
+		{"tanh", x.Tanh()},
+		{"pow gdual", x.PowGDual(x)},
+		{"mul", huge.Mul(huge)},
+		{"pow", huge.Pow(3)},
+		{"propagated", x.Exp().Sub(x).Log()},
+	}
+
+	for i, tt := range tests {
+		if !errors.Is(tt.have.Err(), ErrOverflow) {
+			t.Errorf("error mismatch on %s test %d: have %v want %v", tt.name, i, tt.have.Err(), ErrOverflow)
+		}
+	}
+
+	// exp(-1e10) underflows to zero instead, which is no error
+	if y := negative.Exp(); y.Err() != nil || y.Value().Sign() != 0 {
+		t.Errorf("value mismatch on underflow: have %s and %v", y.Value().Text('g', 10), y.Err())
+	}
+
+	mat := NewBigUpperTriToeplitz(order, prec)
+	if err := mat.ElementDiv(newBigFloat(prec)); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("error mismatch on element division: have %v want %v", err, ErrDivisionByZero)
+	}
+}
+
+func TestBigPowLimits(t *testing.T) {
+	order := 4
+	prec := uint(100)
+
+	// exponents outside of the range of an int
+	huge, _ := new(big.Float).SetString("1e30")
+	tests := []struct {
+		name string
+		have *BigGDual
+	}{
+		{"zero seed", NewBigGDual(order, prec, big.NewFloat(0.0), true).PowReal(huge)},
+		{"min int", NewBigGDual(order, prec, big.NewFloat(2.0), true).Pow(math.MinInt)},
+	}
+
+	for i, tt := range tests {
+		if tt.have.Err() != nil {
+			t.Errorf("unexpected error on %s test %d: %v", tt.name, i, tt.have.Err())
+		}
+
+		for k, coef := range tt.have.Coefficients() {
+			if coef.Sign() != 0 {
+				t.Errorf("value mismatch on %s test %d (col %d): have %s want 0",
+					tt.name, i, k, coef.Text('g', 10))
+			}
+		}
+	}
+}
+
+func TestBigUnitDomain(t *testing.T) {
+	prec := uint(100)
+
+	// like float64, asin, acos and acosh have a value at the ends of
+	// their domains, but no series
+	tests := []struct {
+		name     string
+		seed     float64
+		fn       func(*BigGDual) *BigGDual
+		fn64     func(*GDual) *GDual
+		expected float64
+	}{
+		{"asin", 1.0, (*BigGDual).Asin, (*GDual).Asin, math.Pi / 2},
+		{"asin", -1.0, (*BigGDual).Asin, (*GDual).Asin, -math.Pi / 2},
+		{"acos", 1.0, (*BigGDual).Acos, (*GDual).Acos, 0.0},
+		{"acos", -1.0, (*BigGDual).Acos, (*GDual).Acos, math.Pi},
+		{"acosh", 1.0, (*BigGDual).Acosh, (*GDual).Acosh, 0.0},
+	}
+
+	for i, tt := range tests {
+		value := tt.fn(NewBigGDual(1, prec, big.NewFloat(tt.seed), true))
+		value64 := tt.fn64(NewGDual(1, tt.seed, true))
+		if have, _ := value.Value().Float64(); value.Err() != nil || !almostEqual(have, tt.expected) || value64.Value() != tt.expected {
+			t.Errorf("value mismatch on %s(%g) test %d: have %g (%v) and %g want %g",
+				tt.name, tt.seed, i, have, value.Err(), value64.Value(), tt.expected)
+		}
+
+		series := tt.fn(NewBigGDual(3, prec, big.NewFloat(tt.seed), true))
+		series64 := tt.fn64(NewGDual(3, tt.seed, true))
+		if !errors.Is(series.Err(), ErrDomain) || !math.IsNaN(series64.Value()) || !math.IsNaN(series64.mat.get(1)) {
+			t.Errorf("error mismatch on %s(%g) series test %d: have %v and %v want %v and NaN",
+				tt.name, tt.seed, i, series.Err(), series64.Coefficients(), ErrDomain)
+		}
+	}
+
+	// and nothing past them
+	if y := NewBigGDual(1, prec, big.NewFloat(1.5), true).Asin(); !errors.Is(y.Err(), ErrDomain) {
+		t.Errorf("error mismatch on asin(1.5): have %v want %v", y.Err(), ErrDomain)
+	}
+}
+
+func TestBigDerivatives(t *testing.T) {
+	order := 30
+	prec := uint(128)
+
+	// f(0.0) = exp(2x), where f^(k)(0) = 2^k
+	x := NewBigGDual(order, prec, big.NewFloat(0.0), true)
+	two := NewBigGDual(order, prec, big.NewFloat(2.0), false)
+	y := x.Mul(two).Exp()
+
+	for k, deriv := range y.Derivatives() {
+		expected := new(big.Float).SetMantExp(big.NewFloat(1), k)
+
+		// 2^k / k! isn't exact in binary, so the derivative is only close
+		diff := newBigFloat(prec).Sub(deriv, expected)
+		if diff.Sign() != 0 && bigExponent(diff) > k-120 {
+			t.Errorf("value mismatch on big derivatives (col %d): have %s want %s",
+				k, deriv.Text('g', 20), expected.Text('g', 20))
+		}
+	}
+}
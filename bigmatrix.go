@@ -0,0 +1,671 @@
+/*
+
+upper triangular Toeplitz matrices of big.Float.
+
+the same matrix as UpperTriToeplitz, with the same recurrences for the
+elementary functions (see elementary.go), but every coefficient is a
+big.Float with a fixed precision, so the high order coefficients don't
+drown in float64 rounding error.
+
+big.Float has no NaN, so operations that would produce one (dividing by
+zero, or a seed outside the domain of a function) return an error
+instead: ErrDivisionByZero or ErrDomain. the seeds where a function has
+no Taylor series (like Sqrt or Log at zero) are errors as well, where
+the float64 matrix would fill its coefficients with infinities. the
+domains themselves are the same as for float64, so Sqrt, Asin, Acos and
+Acosh still have a value at the ends of theirs, for a matrix of order 1.
+
+a big.Float can still overflow, past an exponent of MaxExp, and Exp,
+Sinh, Cosh and Tanh return ErrOverflow when their value does. anything
+computed from an infinite coefficient would panic with big.ErrNaN, which
+BigGDual turns into ErrOverflow as well.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+type BigUpperTriToeplitz struct {
+	order int
+	prec  uint
+	val   []*big.Float
+}
+
+// NewBigUpperTriToeplitz creates a matrix with coefficients of prec bits
+func NewBigUpperTriToeplitz(order int, prec uint) *BigUpperTriToeplitz {
+	val := make([]*big.Float, order)
+	for i := range val {
+		val[i] = newBigFloat(prec)
+	}
+
+	mat := &BigUpperTriToeplitz{
+		order: order,
+		prec:  prec,
+		val:   val,
+	}
+
+	return mat
+}
+
+/* utility functions */
+
+// get returns the coefficient itself, which the caller shouldn't modify
+func (m *BigUpperTriToeplitz) get(i int) *big.Float {
+	if i < 0 || i >= m.order {
+		return newBigFloat(m.prec)
+	}
+
+	return m.val[i]
+}
+
+// set rounds val to the precision of the matrix
+func (m *BigUpperTriToeplitz) set(i int, val *big.Float) {
+	if i < 0 || i >= m.order {
+		return
+	}
+
+	m.val[i].Set(val)
+}
+
+func (m *BigUpperTriToeplitz) newFloat() *big.Float {
+	return newBigFloat(m.prec)
+}
+
+func (m *BigUpperTriToeplitz) Order() int {
+	return m.order
+}
+
+func (m *BigUpperTriToeplitz) Prec() uint {
+	return m.prec
+}
+
+// Coefficient returns a copy of the i-th coefficient
+func (m *BigUpperTriToeplitz) Coefficient(i int) *big.Float {
+	return m.newFloat().Set(m.get(i))
+}
+
+func (m *BigUpperTriToeplitz) Fill(diagonal int, val *big.Float) {
+	// fill the given upper diagonal of the matrix
+	m.set(diagonal, val)
+}
+
+func (m *BigUpperTriToeplitz) Reset(val *big.Float) {
+	for i := 0; i < m.order; i++ {
+		m.set(i, val)
+	}
+}
+
+func (m *BigUpperTriToeplitz) Copy() *BigUpperTriToeplitz {
+	copy := NewBigUpperTriToeplitz(m.order, m.prec)
+
+	for i := 0; i < m.order; i++ {
+		val := m.get(i)
+		copy.set(i, val)
+	}
+
+	return copy
+}
+
+/* element-wise matrix operations */
+
+func (m *BigUpperTriToeplitz) ElementAdd(val *big.Float) {
+	for i := 0; i < m.order; i++ {
+		m.val[i].Add(m.val[i], val)
+	}
+}
+
+func (m *BigUpperTriToeplitz) ElementSub(val *big.Float) {
+	for i := 0; i < m.order; i++ {
+		m.val[i].Sub(m.val[i], val)
+	}
+}
+
+func (m *BigUpperTriToeplitz) ElementMul(val *big.Float) {
+	for i := 0; i < m.order; i++ {
+		m.val[i].Mul(m.val[i], val)
+	}
+}
+
+func (m *BigUpperTriToeplitz) ElementDiv(val *big.Float) error {
+	if val.Sign() == 0 {
+		return ErrDivisionByZero
+	}
+
+	for i := 0; i < m.order; i++ {
+		m.val[i].Quo(m.val[i], val)
+	}
+
+	return nil
+}
+
+// overflow returns ErrOverflow if a coefficient has overflowed to an infinity
+func (m *BigUpperTriToeplitz) overflow() error {
+	for i := 0; i < m.order; i++ {
+		if m.val[i].IsInf() {
+			return fmt.Errorf("%w: coefficient %d", ErrOverflow, i)
+		}
+	}
+
+	return nil
+}
+
+/* matrix operations */
+
+// like the float64 matrix, binary operations are truncated to the
+// smaller order, and the result has the precision of the receiver
+
+func (m *BigUpperTriToeplitz) Add(inp *BigUpperTriToeplitz) *BigUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewBigUpperTriToeplitz(order, m.prec)
+	for i := 0; i < order; i++ {
+		out.val[i].Add(m.get(i), inp.get(i))
+	}
+
+	return out
+}
+
+func (m *BigUpperTriToeplitz) Sub(inp *BigUpperTriToeplitz) *BigUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewBigUpperTriToeplitz(order, m.prec)
+	for i := 0; i < order; i++ {
+		out.val[i].Sub(m.get(i), inp.get(i))
+	}
+
+	return out
+}
+
+func (m *BigUpperTriToeplitz) Mul(inp *BigUpperTriToeplitz) *BigUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewBigUpperTriToeplitz(order, m.prec)
+	product := m.newFloat()
+	for i := 0; i < order; i++ {
+		for k := i; k >= 0; k-- {
+			product.Mul(m.get(i-k), inp.get(k))
+			out.val[i].Add(out.val[i], product)
+		}
+	}
+
+	return out
+}
+
+// b_0 = 1 / a_0, b_k = -(1 / a_0) * Σ a_j*b_{k-j}, see Inv in matrix.go
+func (m *BigUpperTriToeplitz) Inv() (*BigUpperTriToeplitz, error) {
+	inv := NewBigUpperTriToeplitz(m.order, m.prec)
+	if m.order == 0 {
+		return inv, nil
+	}
+
+	a := m.get(0)
+	if a.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+
+	inv.val[0].Quo(bigInt(1, m.prec), a)
+	sum, product := m.newFloat(), m.newFloat()
+	for k := 1; k < m.order; k++ {
+		sum.SetInt64(0)
+		for j := 1; j <= k; j++ {
+			product.Mul(m.get(j), inv.get(k-j))
+			sum.Add(sum, product)
+		}
+		inv.val[k].Quo(sum.Neg(sum), a)
+	}
+
+	return inv, nil
+}
+
+func (m *BigUpperTriToeplitz) Div(inp *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+	inv, err := inp.Inv()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Mul(inv), nil
+}
+
+// integer powers, with Miller's recurrence or by squaring (see Pow in matrix.go)
+func (m *BigUpperTriToeplitz) Pow(n int) (*BigUpperTriToeplitz, error) {
+	if n == 0 {
+		out := NewBigUpperTriToeplitz(m.order, m.prec)
+		out.Fill(0, bigInt(1, m.prec))
+
+		return out, nil
+	}
+
+	if n < 0 {
+		inv, err := m.Inv()
+		if err != nil {
+			return nil, err
+		}
+
+		// -n overflows for math.MinInt, which is one more than -math.MaxInt
+		if n == math.MinInt {
+			out, _ := inv.Pow(math.MaxInt)
+
+			return out.Mul(inv), nil
+		}
+
+		return inv.Pow(-n)
+	}
+
+	if m.order > 0 && m.get(0).Sign() != 0 {
+		return m.powMiller(bigInt(n, m.prec)), nil
+	}
+
+	return m.powSquaring(n), nil
+}
+
+func (m *BigUpperTriToeplitz) powSquaring(n int) *BigUpperTriToeplitz {
+	out := NewBigUpperTriToeplitz(m.order, m.prec)
+	out.Fill(0, bigInt(1, m.prec))
+
+	base := m
+	for n > 0 {
+		if n&1 == 1 {
+			out = out.Mul(base)
+		}
+
+		n >>= 1
+		if n > 0 {
+			base = base.Mul(base)
+		}
+	}
+
+	return out
+}
+
+/* elementary functions */
+
+// the derivative of the series, truncated to the same order
+func (m *BigUpperTriToeplitz) derivative() *BigUpperTriToeplitz {
+	out := NewBigUpperTriToeplitz(m.order, m.prec)
+	for k := 1; k < m.order; k++ {
+		out.val[k-1].Mul(m.get(k), bigInt(k, m.prec))
+	}
+
+	return out
+}
+
+// Σ a_{j+off} * b_{k-j}, for j = from..to
+func (m *BigUpperTriToeplitz) convolve(a, b *BigUpperTriToeplitz, k, from, to, off int) *big.Float {
+	sum, product := m.newFloat(), m.newFloat()
+	for j := from; j <= to; j++ {
+		product.Mul(a.get(j+off), b.get(k-j))
+		sum.Add(sum, product)
+	}
+
+	return sum
+}
+
+// b' = b * a'  =>  b_k = 1/k * Σ j*a_j*b_{k-j}
+func (m *BigUpperTriToeplitz) Exp() (*BigUpperTriToeplitz, error) {
+	out := NewBigUpperTriToeplitz(m.order, m.prec)
+	if m.order == 0 {
+		return out, nil
+	}
+
+	b := bigExp(m.get(0), m.prec)
+	if b.IsInf() {
+		return nil, fmt.Errorf("%w: exp(%s)", ErrOverflow, m.get(0).Text('g', 10))
+	}
+
+	da := m.derivative()
+	out.set(0, b)
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(da, out, k, 1, k, -1)
+		out.val[k].Quo(sum, bigInt(k, m.prec))
+	}
+
+	return out, nil
+}
+
+// a * b' = a'  =>  b_k = (a_k - 1/k * Σ j*b_j*a_{k-j}) / a_0
+func (m *BigUpperTriToeplitz) Log() (*BigUpperTriToeplitz, error) {
+	out := NewBigUpperTriToeplitz(m.order, m.prec)
+	if m.order == 0 {
+		return out, nil
+	}
+
+	a := m.get(0)
+	if a.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: log(%s)", ErrDomain, a.Text('g', 10))
+	}
+
+	// db holds the derivative of out, as far as it's known
+	db := NewBigUpperTriToeplitz(m.order, m.prec)
+
+	out.set(0, bigLog(a, m.prec))
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(db, m, k, 1, k-1, -1)
+		sum.Quo(sum, bigInt(k, m.prec))
+		sum.Sub(m.get(k), sum)
+		out.val[k].Quo(sum, a)
+		db.val[k-1].Mul(out.get(k), bigInt(k, m.prec))
+	}
+
+	return out, nil
+}
+
+// b * b = a  =>  b_k = (a_k - Σ b_j*b_{k-j}) / 2b_0
+func (m *BigUpperTriToeplitz) Sqrt() (*BigUpperTriToeplitz, error) {
+	if m.order == 0 {
+		return NewBigUpperTriToeplitz(m.order, m.prec), nil
+	}
+
+	a := m.get(0)
+	if a.Sign() < 0 || (a.Sign() == 0 && m.order > 1) {
+		return nil, fmt.Errorf("%w: sqrt(%s)", ErrDomain, a.Text('g', 10))
+	}
+
+	return m.sqrtWith(bigSqrt(a, m.prec)), nil
+}
+
+func (m *BigUpperTriToeplitz) sqrtWith(b *big.Float) *BigUpperTriToeplitz {
+	out := NewBigUpperTriToeplitz(m.order, m.prec)
+	if m.order == 0 {
+		return out
+	}
+
+	out.set(0, b)
+	twoB := m.newFloat().Mul(b, bigInt(2, m.prec))
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(out, out, k, 1, k-1, 0)
+		sum.Sub(m.get(k), sum)
+		out.val[k].Quo(sum, twoB)
+	}
+
+	return out
+}
+
+// s' = c * a' and c' = ∓s * a', for sin and cos (sign = -1) or sinh and cosh (sign = 1)
+func (m *BigUpperTriToeplitz) pair(s0, c0 *big.Float, sign int) (*BigUpperTriToeplitz, *BigUpperTriToeplitz) {
+	s := NewBigUpperTriToeplitz(m.order, m.prec)
+	c := NewBigUpperTriToeplitz(m.order, m.prec)
+	if m.order == 0 {
+		return s, c
+	}
+
+	da := m.derivative()
+	s.set(0, s0)
+	c.set(0, c0)
+	for k := 1; k < m.order; k++ {
+		fk := bigInt(k, m.prec)
+
+		sumS := m.convolve(da, c, k, 1, k, -1)
+		sumC := m.convolve(da, s, k, 1, k, -1)
+		if sign < 0 {
+			sumC.Neg(sumC)
+		}
+
+		s.val[k].Quo(sumS, fk)
+		c.val[k].Quo(sumC, fk)
+	}
+
+	return s, c
+}
+
+func (m *BigUpperTriToeplitz) sinCos() (*BigUpperTriToeplitz, *BigUpperTriToeplitz) {
+	var s0, c0 *big.Float
+	if m.order > 0 {
+		s0, c0 = bigSinCos(m.get(0), m.prec)
+	}
+
+	return m.pair(s0, c0, -1)
+}
+
+func (m *BigUpperTriToeplitz) Sin() *BigUpperTriToeplitz {
+	sin, _ := m.sinCos()
+
+	return sin
+}
+
+func (m *BigUpperTriToeplitz) Cos() *BigUpperTriToeplitz {
+	_, cos := m.sinCos()
+
+	return cos
+}
+
+// tan = sin / cos, where cos is never exactly zero at a finite precision
+func (m *BigUpperTriToeplitz) Tan() *BigUpperTriToeplitz {
+	sin, cos := m.sinCos()
+	tan, _ := sin.Div(cos)
+
+	return tan
+}
+
+// sinh and cosh overflow together, once exp(|a_0|) does
+func (m *BigUpperTriToeplitz) sinhCosh() (*BigUpperTriToeplitz, *BigUpperTriToeplitz, error) {
+	var s0, c0 *big.Float
+	if m.order > 0 {
+		s0, c0 = bigSinhCosh(m.get(0), m.prec)
+		if c0.IsInf() {
+			return nil, nil, fmt.Errorf("%w: cosh(%s)", ErrOverflow, m.get(0).Text('g', 10))
+		}
+	}
+
+	sinh, cosh := m.pair(s0, c0, 1)
+
+	return sinh, cosh, nil
+}
+
+func (m *BigUpperTriToeplitz) Sinh() (*BigUpperTriToeplitz, error) {
+	sinh, _, err := m.sinhCosh()
+
+	return sinh, err
+}
+
+func (m *BigUpperTriToeplitz) Cosh() (*BigUpperTriToeplitz, error) {
+	_, cosh, err := m.sinhCosh()
+
+	return cosh, err
+}
+
+// tanh = sinh / cosh, where cosh >= 1. tanh itself never overflows, but
+// this still does when sinh and cosh do.
+func (m *BigUpperTriToeplitz) Tanh() (*BigUpperTriToeplitz, error) {
+	sinh, cosh, err := m.sinhCosh()
+	if err != nil {
+		return nil, err
+	}
+
+	return sinh.Div(cosh)
+}
+
+/* inverse functions */
+
+// b_k = (p_{k-1} - Σ j*b_j*u_{k-j}) / (k*u_0), see integrateQuotient in elementary.go
+func (m *BigUpperTriToeplitz) integrateQuotient(b0 *big.Float, p, u *BigUpperTriToeplitz) *BigUpperTriToeplitz {
+	out := NewBigUpperTriToeplitz(u.order, m.prec)
+	if u.order == 0 {
+		return out
+	}
+
+	// db holds the derivative of out, as far as it's known
+	db := NewBigUpperTriToeplitz(u.order, m.prec)
+
+	out.set(0, b0)
+	den := m.newFloat()
+	for k := 1; k < u.order; k++ {
+		fk := bigInt(k, m.prec)
+
+		sum := m.convolve(db, u, k, 1, k-1, -1)
+		sum.Sub(p.get(k-1), sum)
+		out.val[k].Quo(sum, den.Mul(fk, u.get(0)))
+		db.val[k-1].Mul(out.get(k), fk)
+	}
+
+	return out
+}
+
+func (m *BigUpperTriToeplitz) one() *BigUpperTriToeplitz {
+	one := NewBigUpperTriToeplitz(m.order, m.prec)
+	one.Fill(0, bigInt(1, m.prec))
+
+	return one
+}
+
+// whether the seed is strictly inside of (-1, 1)
+func (m *BigUpperTriToeplitz) insideUnit() bool {
+	return m.order == 0 || new(big.Float).Abs(m.get(0)).Cmp(big.NewFloat(1)) < 0
+}
+
+// whether the seed is inside of [-1, 1], where only the value exists at the ends
+func (m *BigUpperTriToeplitz) closedUnit() bool {
+	return m.insideUnit() || (m.order == 1 && new(big.Float).Abs(m.get(0)).Cmp(big.NewFloat(1)) == 0)
+}
+
+// b' = a' / sqrt(1 - a^2), defined for a_0 in [-1, 1]
+func (m *BigUpperTriToeplitz) Asin() (*BigUpperTriToeplitz, error) {
+	if !m.closedUnit() {
+		return nil, fmt.Errorf("%w: asin(%s)", ErrDomain, m.get(0).Text('g', 10))
+	}
+
+	u, _ := m.one().Sub(m.Mul(m)).Sqrt()
+
+	return m.integrateQuotient(bigAsin(m.get(0), m.prec), m.derivative(), u), nil
+}
+
+// b' = -a' / sqrt(1 - a^2), defined for a_0 in [-1, 1]
+func (m *BigUpperTriToeplitz) Acos() (*BigUpperTriToeplitz, error) {
+	if !m.closedUnit() {
+		return nil, fmt.Errorf("%w: acos(%s)", ErrDomain, m.get(0).Text('g', 10))
+	}
+
+	u, _ := m.one().Sub(m.Mul(m)).Sqrt()
+	u.ElementMul(bigInt(-1, m.prec))
+
+	return m.integrateQuotient(bigAcos(m.get(0), m.prec), m.derivative(), u), nil
+}
+
+// b' = a' / (1 + a^2)
+func (m *BigUpperTriToeplitz) Atan() *BigUpperTriToeplitz {
+	u := m.one().Add(m.Mul(m))
+
+	return m.integrateQuotient(bigAtan(m.get(0), m.prec), m.derivative(), u)
+}
+
+// b' = (x*y' - y*x') / (x^2 + y^2), where the receiver is y
+func (m *BigUpperTriToeplitz) Atan2(x *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+	if minOrder(m.order, x.order) > 0 && m.get(0).Sign() == 0 && x.get(0).Sign() == 0 {
+		return nil, fmt.Errorf("%w: atan2(0, 0)", ErrDomain)
+	}
+
+	p := x.Mul(m.derivative()).Sub(m.Mul(x.derivative()))
+	u := x.Mul(x).Add(m.Mul(m))
+
+	return m.integrateQuotient(bigAtan2(m.get(0), x.get(0), m.prec), p, u), nil
+}
+
+// b' = a' / sqrt(a^2 + 1)
+func (m *BigUpperTriToeplitz) Asinh() *BigUpperTriToeplitz {
+	u, _ := m.Mul(m).Add(m.one()).Sqrt()
+
+	return m.integrateQuotient(bigAsinh(m.get(0), m.prec), m.derivative(), u)
+}
+
+// b' = a' / sqrt(a^2 - 1), defined for a_0 in [1, inf), where only the value exists at 1
+func (m *BigUpperTriToeplitz) Acosh() (*BigUpperTriToeplitz, error) {
+	if cmp := m.get(0).Cmp(big.NewFloat(1)); m.order > 0 && (cmp < 0 || (cmp == 0 && m.order > 1)) {
+		return nil, fmt.Errorf("%w: acosh(%s)", ErrDomain, m.get(0).Text('g', 10))
+	}
+
+	u, _ := m.Mul(m).Sub(m.one()).Sqrt()
+
+	return m.integrateQuotient(bigAcosh(m.get(0), m.prec), m.derivative(), u), nil
+}
+
+// b' = a' / (1 - a^2), defined for a_0 in (-1, 1)
+func (m *BigUpperTriToeplitz) Atanh() (*BigUpperTriToeplitz, error) {
+	if !m.insideUnit() {
+		return nil, fmt.Errorf("%w: atanh(%s)", ErrDomain, m.get(0).Text('g', 10))
+	}
+
+	u := m.one().Sub(m.Mul(m))
+
+	return m.integrateQuotient(bigAtanh(m.get(0), m.prec), m.derivative(), u), nil
+}
+
+/* powers */
+
+// a^p with Miller's recurrence, see PowReal in elementary.go. a negative
+// seed only has a real power for an integer exponent, and a zero seed's
+// power is all zeros from p = order on.
+func (m *BigUpperTriToeplitz) PowReal(p *big.Float) (*BigUpperTriToeplitz, error) {
+	if m.order == 0 {
+		return NewBigUpperTriToeplitz(m.order, m.prec), nil
+	}
+
+	a := m.get(0)
+	if a.Sign() == 0 {
+		if p.Sign() < 0 || !p.IsInt() {
+			return nil, fmt.Errorf("%w: 0^%s", ErrDomain, p.Text('g', 10))
+		}
+
+		if p.Cmp(bigInt(m.order, m.prec)) >= 0 {
+			return NewBigUpperTriToeplitz(m.order, m.prec), nil
+		}
+
+		n, _ := p.Int64()
+
+		return m.Pow(int(n))
+	}
+
+	if a.Sign() < 0 && !p.IsInt() {
+		return nil, fmt.Errorf("%w: %s^%s", ErrDomain, a.Text('g', 10), p.Text('g', 10))
+	}
+
+	return m.powMiller(p), nil
+}
+
+func (m *BigUpperTriToeplitz) powMiller(p *big.Float) *BigUpperTriToeplitz {
+	out := NewBigUpperTriToeplitz(m.order, m.prec)
+	if m.order == 0 {
+		return out
+	}
+
+	da := m.derivative()
+	pPlus := m.newFloat().Add(p, bigInt(1, m.prec))
+
+	a := m.get(0)
+	out.set(0, bigPow(a, p, m.prec))
+	den := m.newFloat()
+	for k := 1; k < m.order; k++ {
+		fk := bigInt(k, m.prec)
+
+		sumJ := m.convolve(da, out, k, 1, k, -1)
+		sum := m.convolve(m, out, k, 1, k, 0)
+		sumJ.Mul(sumJ, pPlus)
+		sum.Mul(sum, fk)
+		sumJ.Sub(sumJ, sum)
+		out.val[k].Quo(sumJ, den.Mul(fk, a))
+	}
+
+	return out
+}
+
+// a^e = exp(e * log(a)), which needs a_0 > 0 unless e is a constant
+func (m *BigUpperTriToeplitz) PowBig(e *BigUpperTriToeplitz) (*BigUpperTriToeplitz, error) {
+	constant := true
+	for i := 1; i < e.order; i++ {
+		if e.get(i).Sign() != 0 {
+			constant = false
+			break
+		}
+	}
+
+	if constant {
+		return m.PowReal(e.get(0))
+	}
+
+	log, err := m.Log()
+	if err != nil {
+		return nil, err
+	}
+
+	return log.Mul(e).Exp()
+}
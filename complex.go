@@ -0,0 +1,52 @@
+/*
+
+complex generalized dual numbers.
+
+a GDual over complex128 is the Taylor expansion of a holomorphic
+function around a complex seed. the arithmetic and the recurrences of
+the elementary functions don't change at all: only the value at the
+seed, b_0, is computed differently, with math/cmplx instead of math.
+
+that value is the one place where branches come in. every function
+here takes the principal branch of math/cmplx, and the higher order
+coefficients are the derivatives of that same branch, so the series
+always agrees with math/cmplx in a neighbourhood of the seed that
+doesn't cross a branch cut:
+
+	Log, Sqrt, PowReal, PowGDual    (-inf, 0]
+	Asin, Acos, Atanh               (-inf, -1] and [1, inf)
+	Acosh                           (-inf, 1]
+	Atan, Asinh                     (-i*inf, -i] and [i, i*inf)
+
+a seed that lies exactly on a cut gets whichever side math/cmplx picks
+for its value. for Log, Sqrt, Pow, Asin, Acos and Acosh that's the side
+given by the sign of the zero imaginary part (the real part for Asinh),
+so that cmplx.Log(-1+0i) is iπ and cmplx.Log(complex(-1, math.Copysign(0, -1)))
+is -iπ, while Atan and Atanh ignore the sign. either way, the series continues the
+function from the side its value is on (the square roots in the
+derivatives of the inverse functions are picked to match, see
+sqrtOnBranch), so it only matches math/cmplx on that side of the cut.
+
+the seeds that real numbers leave undefined (Log(-1) or Asin(2), for
+example) are all well defined here, so the NaN domain checks only apply
+to real element types. the branch points themselves (Log(0),
+Sqrt(0), Asin(±1), ...) still have no Taylor series, and produce
+infinite or NaN coefficients the same way they do for real seeds.
+
+*/
+
+package gdual
+
+// ComplexGDual is a GDual with complex coefficients
+type ComplexGDual = GDualOf[complex128]
+
+// ComplexUpperTriToeplitz is an UpperTriToeplitz with complex coefficients
+type ComplexUpperTriToeplitz = UpperTriToeplitzOf[complex128]
+
+func NewComplexGDual(order int, seed complex128, variable bool) *ComplexGDual {
+	return NewGDualOf(order, seed, variable)
+}
+
+func NewComplexUpperTriToeplitz(order int) *ComplexUpperTriToeplitz {
+	return NewUpperTriToeplitzOf[complex128](order)
+}
@@ -0,0 +1,190 @@
+package gdual
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// sums the truncated Taylor series at an offset h from the seed
+func evalSeries(coefs []complex128, h complex128) complex128 {
+	var sum complex128
+	for k := len(coefs) - 1; k >= 0; k-- {
+		sum = sum*h + coefs[k]
+	}
+
+	return sum
+}
+
+// offsets of radius r around the seed, with angles in [from, to]
+func offsets(r, from, to float64, n int) []complex128 {
+	out := make([]complex128, n)
+	for i := range out {
+		theta := from + (to-from)*float64(i)/float64(n-1)
+		out[i] = cmplx.Rect(r, theta)
+	}
+
+	return out
+}
+
+func complexClose(a, b complex128) bool {
+	return cmplx.Abs(a-b) <= 1e-10*math.Max(1, cmplx.Abs(b))
+}
+
+func TestComplexFunctions(t *testing.T) {
+	order := 20
+
+	// none of these seeds are within 0.3 of a branch cut
+	seeds := []complex128{
+		complex(0.5, 0.5),
+		complex(-1.5, 0.7),
+		complex(-0.8, -1.2),
+		complex(2.0, -0.3),
+		complex(0.3, 2.5),
+	}
+
+	tests := []struct {
+		name     string
+		series   func(x *ComplexGDual) *ComplexGDual
+		expected func(z complex128) complex128
+	}{
+		{"exp", (*ComplexGDual).Exp, cmplx.Exp},
+		{"log", (*ComplexGDual).Log, cmplx.Log},
+		{"sqrt", (*ComplexGDual).Sqrt, cmplx.Sqrt},
+		{"sin", (*ComplexGDual).Sin, cmplx.Sin},
+		{"cos", (*ComplexGDual).Cos, cmplx.Cos},
+		{"tan", (*ComplexGDual).Tan, cmplx.Tan},
+		{"sinh", (*ComplexGDual).Sinh, cmplx.Sinh},
+		{"cosh", (*ComplexGDual).Cosh, cmplx.Cosh},
+		{"tanh", (*ComplexGDual).Tanh, cmplx.Tanh},
+		{"asin", (*ComplexGDual).Asin, cmplx.Asin},
+		{"acos", (*ComplexGDual).Acos, cmplx.Acos},
+		{"atan", (*ComplexGDual).Atan, cmplx.Atan},
+		{"asinh", (*ComplexGDual).Asinh, cmplx.Asinh},
+		{"acosh", (*ComplexGDual).Acosh, cmplx.Acosh},
+		{"atanh", (*ComplexGDual).Atanh, cmplx.Atanh},
+		{"pow z^1.5", func(x *ComplexGDual) *ComplexGDual {
+			return x.PowReal(1.5)
+		}, func(z complex128) complex128 {
+			return cmplx.Pow(z, 1.5)
+		}},
+		{"transfer 1/(z^2 + 0.2z + 1)", func(x *ComplexGDual) *ComplexGDual {
+			one := NewComplexGDual(x.Order(), 1.0, false)
+			damping := NewComplexGDual(x.Order(), 0.2, false)
+
+			return one.Div(x.Pow(2).Add(damping.Mul(x)).Add(one))
+		}, func(z complex128) complex128 {
+			return 1 / (z*z + 0.2*z + 1)
+		}},
+	}
+
+	for _, seed := range seeds {
+		x := NewComplexGDual(order, seed, true)
+		for i, tt := range tests {
+			coefs := tt.series(x).Coefficients()
+			for _, h := range offsets(0.05, -math.Pi, math.Pi, 8) {
+				have := evalSeries(coefs, h)
+				expected := tt.expected(seed + h)
+				if !complexClose(have, expected) {
+					t.Errorf("value mismatch on %s test %d (seed %v, offset %v): have %v want %v",
+						tt.name, i, seed, h, have, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestComplexBranchCuts(t *testing.T) {
+	order := 20
+	negZero := math.Copysign(0, -1)
+
+	tests := []struct {
+		name     string
+		series   func(x *ComplexGDual) *ComplexGDual
+		expected func(z complex128) complex128
+		seed     float64
+	}{
+		{"log", (*ComplexGDual).Log, cmplx.Log, -2.0},
+		{"sqrt", (*ComplexGDual).Sqrt, cmplx.Sqrt, -2.0},
+		{"asin", (*ComplexGDual).Asin, cmplx.Asin, 1.5},
+		{"acosh", (*ComplexGDual).Acosh, cmplx.Acosh, 0.0},
+		{"acos", (*ComplexGDual).Acos, cmplx.Acos, -1.5},
+		{"pow z^1.5", func(x *ComplexGDual) *ComplexGDual {
+			return x.PowReal(1.5)
+		}, func(z complex128) complex128 {
+			return cmplx.Pow(z, 1.5)
+		}, -2.0},
+	}
+
+	for i, tt := range tests {
+		// the seed takes the side of the cut given by the sign of its zero,
+		// and the series only matches math/cmplx on that side
+		above := complex(tt.seed, 0)
+		below := complex(tt.seed, negZero)
+
+		sides := []struct {
+			seed     complex128
+			from, to float64
+		}{
+			{above, 0.1, math.Pi - 0.1},
+			{below, -math.Pi + 0.1, -0.1},
+		}
+
+		for _, side := range sides {
+			x := NewComplexGDual(order, side.seed, true)
+			y := tt.series(x)
+
+			if y.Value() != tt.expected(side.seed) {
+				t.Errorf("value mismatch on %s branch test %d (seed %v): have %v want %v",
+					tt.name, i, side.seed, y.Value(), tt.expected(side.seed))
+			}
+
+			coefs := y.Coefficients()
+			for _, h := range offsets(0.05, side.from, side.to, 6) {
+				have := evalSeries(coefs, h)
+				expected := tt.expected(side.seed + h)
+				if !complexClose(have, expected) {
+					t.Errorf("value mismatch on %s branch test %d (seed %v, offset %v): have %v want %v",
+						tt.name, i, side.seed, h, have, expected)
+				}
+			}
+		}
+	}
+
+	// log jumps by 2πi across the cut, but its derivatives don't
+	up := NewComplexGDual(order, complex(-2.0, 0), true).Log()
+	down := NewComplexGDual(order, complex(-2.0, negZero), true).Log()
+	if jump := up.Value() - down.Value(); !complexClose(jump, complex(0, 2*math.Pi)) {
+		t.Errorf("value mismatch on log jump: have %v want %v", jump, complex(0, 2*math.Pi))
+	}
+
+	for k := 1; k < order; k++ {
+		have, _ := up.Coefficient(k)
+		expected, _ := down.Coefficient(k)
+		if have != expected {
+			t.Errorf("value mismatch on log jump (col %d): have %v want %v", k, have, expected)
+		}
+	}
+}
+
+func TestComplexRealSeeds(t *testing.T) {
+	order := 10
+
+	// real seeds outside the real domain are defined in the complex plane
+	x := NewComplexGDual(order, -1.0, true)
+	y := x.Log()
+	if !complexClose(y.Value(), complex(0, math.Pi)) {
+		t.Errorf("value mismatch on complex log(-1): have %v want %v", y.Value(), complex(0, math.Pi))
+	}
+
+	// and real seeds inside it match the real series
+	z := NewComplexGDual(order, 0.3, true).Asin()
+	r := NewGDual(order, 0.3, true).Asin()
+	for k := 0; k < order; k++ {
+		have, _ := z.Coefficient(k)
+		expected, _ := r.Coefficient(k)
+		if !complexClose(have, complex(expected, 0)) {
+			t.Errorf("value mismatch on complex asin test (col %d): have %v want %v", k, have, expected)
+		}
+	}
+}
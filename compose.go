@@ -0,0 +1,158 @@
+/*
+
+series composition.
+
+given the Taylor series of an outer function f around y0, and a dual
+number g with the value y0, composing the two gives the series of
+f(g(x)), which is the chain rule (Faà di Bruno's formula) to every
+order at once, without needing anything else from f.
+
+g is split into its value and its nilpotent part, g = y0 + h, where h
+has no constant term. then
+
+	f(g) = Σ c_k * h^k,   where c_k = f^(k)(y0) / k!
+
+and since h^k only starts at x^k, the sum stops at the order. it's
+evaluated with Horner's rule, r = c_k + h*r, which takes one product
+per order: O(n^3) in all, or O(n^2 logn) when the products can use
+the FFT. Brent and Kung's algorithm would do better at high orders,
+but the elementary functions (see elementary.go) are all O(n^2), so
+composition is only worth it for functions we don't have.
+
+reversion goes the other way: from the series of y = f(x) around x0,
+it gives the series of the inverse function x = f^-1(y) around f(x0),
+so functions we can only evaluate forwards can still be inverted.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+)
+
+// Compose returns outer(inner), where outer holds the Taylor coefficients
+// of a function around the value of inner, in the order of Coefficients.
+// the result is truncated to the smaller order of the two.
+func Compose[T Field](outer []T, inner *GDualOf[T]) *GDualOf[T] {
+	mat := apply(inner.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+		return importUpperTriToeplitz(outer).Compose(m)
+	})
+	gdual := importGDual(mat, inner)
+
+	return gdual
+}
+
+// Compose returns g(inner), where g holds the Taylor coefficients of a
+// function around the value of inner, like Compose. only the variables
+// of inner carry over to the result.
+func (g *GDualOf[T]) Compose(inner *GDualOf[T]) *GDualOf[T] {
+	g.mix(inner)
+	mat := apply(inner.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+		return g.mat.toeplitz().Compose(m)
+	})
+	gdual := importGDual(mat, inner)
+
+	return gdual
+}
+
+// SetCompose sets z to outer(inner), see Compose
+func (z *GDualOf[T]) SetCompose(outer, inner *GDualOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(outer, inner); ok {
+		mat.SetCompose(outer.mat.toeplitz(), inner.mat.toeplitz())
+	} else {
+		z.mat = apply(inner.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+			return outer.mat.toeplitz().Compose(m)
+		})
+	}
+	z.inherit(inner, nil)
+
+	return z
+}
+
+// Compose returns the series m, taken around the value of inner, composed with inner
+func (m *UpperTriToeplitzOf[T]) Compose(inner *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	return m.result().SetCompose(m, inner).detach()
+}
+
+func (z *UpperTriToeplitzOf[T]) SetCompose(outer, inner *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := minOrder(outer.order, inner.order)
+	outer = z.input(outer, 1)
+
+	// the nilpotent part of inner
+	h := z.temp(0, order)
+	for k := 1; k < order; k++ {
+		h.set(k, inner.get(k))
+	}
+
+	z.resize(order)
+	z.Reset(0)
+	for k := order - 1; k >= 0; k-- {
+		z.SetMul(z, h)
+		z.set(0, z.get(0)+outer.get(k))
+	}
+
+	return z
+}
+
+/* reversion */
+
+/*
+Revert returns the series of the inverse function. with g = y0 + h, the
+inverse is x0 + b, where b(h(t)) = t. the value x0 isn't kept by a dual
+number, so the result has a value of zero, and AddScalar(x0) gives the
+whole series. the inverse only exists when the linear coefficient is
+nonzero, and ErrNotInvertible is returned otherwise.
+*/
+func (g *GDualOf[T]) Revert() (*GDualOf[T], error) {
+	mat, err := g.mat.toeplitz().Revert()
+	if err != nil {
+		return nil, err
+	}
+	gdual := importGDual(g.mat.from(mat), g)
+
+	return gdual, nil
+}
+
+/*
+Lagrange inversion. with h = a_1*t + a_2*t^2 + ..., and q = t / h, which
+is the inverse of the series a_1 + a_2*t + ..., the coefficients of the
+inverse are
+
+b_k = 1/k * [t^(k-1)] q^k
+
+where [t^j] is the coefficient of t^j. every power of q is one product
+more than the last, so this is O(n^3).
+*/
+func (m *UpperTriToeplitzOf[T]) Revert() (*UpperTriToeplitzOf[T], error) {
+	out := m.result()
+	out.resize(m.order)
+	out.Reset(0)
+	if m.order < 2 {
+		return out, nil
+	}
+
+	// the coefficients of h, shifted down by one. a small linear
+	// coefficient is still invertible, only a singular one isn't.
+	q := out.temp(0, m.order-1)
+	for k := 1; k < m.order; k++ {
+		q.set(k-1, m.get(k))
+	}
+
+	if q.singular() {
+		out.release()
+
+		return nil, fmt.Errorf("%w: the linear coefficient is zero", ErrNotInvertible)
+	}
+	q.SetInv(q)
+
+	power := out.temp(1, m.order-1).Set(q)
+	for k := 1; k < m.order; k++ {
+		out.set(k, power.get(k-1)/fromInt[T](k))
+		if k+1 < m.order {
+			power.SetMul(power, q)
+		}
+	}
+
+	return out.detach(), nil
+}
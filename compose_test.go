@@ -0,0 +1,173 @@
+package gdual
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	order := 9
+	seed := 0.4
+
+	x := NewGDual(order, seed, true)
+	inner := x.Sin().Mul(x.Exp())
+	y0 := inner.Value()
+
+	// the outer series, from their derivatives at y0
+	exp := taylorCoefficients(order, func(k int) float64 {
+		return math.Exp(y0)
+	})
+	log := taylorCoefficients(order, func(k int) float64 {
+		if k == 0 {
+			return math.Log(y0)
+		}
+
+		return math.Pow(-1, float64(k-1)) * factorial(k-1) / math.Pow(y0, float64(k))
+	})
+
+	tests := []struct {
+		name     string
+		have     *GDual
+		expected *GDual
+	}{
+		{"exp", Compose(exp, inner), inner.Exp()},
+		{"log", Compose(log, inner), inner.Log()},
+		{"gdual", NewGDual(order, y0, true).Atan().Compose(inner), inner.Atan()},
+		{"destination", new(GDual).SetCompose(NewGDual(order, y0, true).Sqrt(), inner), inner.Sqrt()},
+		{"identity", Compose([]float64{y0, 1}, inner), inner.Mul(NewGDual(2, 1.0, false))},
+	}
+
+	for i, tt := range tests {
+		if tt.have.Order() != tt.expected.Order() {
+			t.Fatalf("order mismatch on %s test %d: have %d want %d", tt.name, i, tt.have.Order(), tt.expected.Order())
+		}
+
+		for k := 0; k < tt.expected.Order(); k++ {
+			if !almostEqual(tt.have.mat.get(k), tt.expected.mat.get(k)) {
+				t.Errorf("value mismatch on %s test %d (col %d): have %v want %v",
+					tt.name, i, k, tt.have.mat.get(k), tt.expected.mat.get(k))
+			}
+		}
+
+		if !tt.have.IsVariable() {
+			t.Errorf("expected variable on %s test %d", tt.name, i)
+		}
+	}
+}
+
+func TestComposeChainRule(t *testing.T) {
+	order := 6
+
+	// f(y) = y^3 + 2y around y0 = 1, with g(x) = x^2 at x0 = 1
+	outer := []float64{3, 5, 3, 1}
+	inner := NewGDual(order, 1.0, true).Pow(2)
+
+	// f(g(x)) = x^6 + 2x^2, so (f∘g)' = 6x^5 + 4x and (f∘g)'' = 30x^4 + 4
+	have := Compose(outer, inner)
+	if have.Order() != len(outer) {
+		t.Fatalf("order mismatch on chain rule: have %d want %d", have.Order(), len(outer))
+	}
+
+	for k, want := range []float64{3, 10, 34, 120} {
+		if deriv, _ := have.Derivative(k); !almostEqual(deriv, want) {
+			t.Errorf("value mismatch on chain rule (derivative %d): have %v want %v", k, deriv, want)
+		}
+	}
+
+	// with the receiver as the inner series, and then as the outer one
+	x := NewGDual(order, 0.3, true)
+	z := x.Sin()
+	expected := z.Exp()
+	z.SetCompose(NewGDual(order, z.Value(), true).Exp(), z)
+
+	w := NewGDual(order, x.Sin().Value(), true).Exp()
+	w.SetCompose(w, x.Sin())
+
+	for k := 0; k < order; k++ {
+		if !almostEqual(z.mat.get(k), expected.mat.get(k)) || !almostEqual(w.mat.get(k), expected.mat.get(k)) {
+			t.Errorf("value mismatch on aliased composition (col %d): have %v and %v want %v",
+				k, z.mat.get(k), w.mat.get(k), expected.mat.get(k))
+		}
+	}
+}
+
+func TestRevert(t *testing.T) {
+	order := 8
+
+	// Kepler's equation, M = E - e*sin(E), which can't be solved for E directly
+	e, e0 := 0.3, 1.1
+	E := NewGDual(order, e0, true)
+	M := E.Sub(E.Sin().MulScalar(e))
+
+	inverse, err := M.Revert()
+	if err != nil {
+		t.Fatalf("failed on kepler reversion: %v", err)
+	}
+
+	// dE/dM = 1 / (1 - e*cos(E)) and d^2E/dM^2 = -e*sin(E) / (1 - e*cos(E))^3
+	d := 1 - e*math.Cos(e0)
+	for k, want := range []float64{0, 1 / d, -e * math.Sin(e0) / (d * d * d)} {
+		if have, _ := inverse.Derivative(k); !almostEqual(have, want) {
+			t.Errorf("value mismatch on kepler reversion (derivative %d): have %v want %v", k, have, want)
+		}
+	}
+
+	// composing the series with its inverse gives back the identity, y0 + t
+	identity := Compose(M.Coefficients(), inverse.AddScalar(e0))
+	for k := 0; k < order; k++ {
+		want := 0.0
+		switch k {
+		case 0:
+			want = M.Value()
+		case 1:
+			want = 1.0
+		}
+
+		if have := identity.mat.get(k); !almostEqual(have, want) {
+			t.Errorf("value mismatch on kepler identity (col %d): have %v want %v", k, have, want)
+		}
+	}
+
+	// the inverse of exp is log, around exp(x0)
+	x0 := 0.5
+	y0 := math.Exp(x0)
+	logs, err := NewGDual(order, x0, true).Exp().Revert()
+	if err != nil {
+		t.Fatalf("failed on exp reversion: %v", err)
+	}
+
+	expected := NewGDual(order, y0, true).Log().SubScalar(x0)
+	for k := 0; k < order; k++ {
+		if !almostEqual(logs.mat.get(k), expected.mat.get(k)) {
+			t.Errorf("value mismatch on exp reversion (col %d): have %v want %v", k, logs.mat.get(k), expected.mat.get(k))
+		}
+	}
+
+	if !logs.IsVariable() {
+		t.Errorf("expected variable on exp reversion")
+	}
+}
+
+func TestRevertErrors(t *testing.T) {
+	// x^3 flattens out at zero, so its inverse has no series there
+	x := NewGDual(5, 0.0, true)
+	if _, err := x.Pow(3).Revert(); !errors.Is(err, ErrNotInvertible) {
+		t.Errorf("error mismatch on revert: have %v want %v", err, ErrNotInvertible)
+	}
+
+	// but only the linear coefficient matters, not the value
+	if _, err := x.Pow(3).Add(x).AddScalar(1e20).Revert(); err != nil {
+		t.Errorf("unexpected error on revert: %v", err)
+	}
+
+	// nor its size: the inverse of 1e-15x + x^2 starts with 1e15y
+	inverse, err := x.MulScalar(1e-15).Add(x.Pow(2)).Revert()
+	if err != nil {
+		t.Fatalf("unexpected error on revert: %v", err)
+	}
+
+	if !almostEqual(inverse.mat.get(1)/1e15, 1.0) {
+		t.Errorf("value mismatch on revert: have %v want %v", inverse.mat.get(1), 1e15)
+	}
+}
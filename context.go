@@ -0,0 +1,138 @@
+/*
+
+contexts, for declaring variables and constants.
+
+NewGDual tells variables and constants apart with a flag, and leaves it
+to the caller to give every number of an expression the same order. a
+Context holds the order instead, and declares every variable by name:
+
+	ctx := NewContext(5)
+	x, err := ctx.Var("x", 2.0)
+	four := ctx.Const(4.0)
+
+	// f(2.0) = 4*x^2
+	y := x.Pow(2).Mul(four)
+
+every result keeps track of the variable it depends on, which
+Variables returns (here, just "x").
+
+a GDual has a single direction of differentiation, so a context has a
+single variable: declaring a second one returns ErrTooManyVariables, and
+declaring the same one again returns ErrRedeclared. with more variables,
+build the expression as an Expr and evaluate it with EvalMulti, which
+gives every partial derivative.
+
+every value remembers its context. combining values of two contexts, or
+a value of a context with one of a different order (such as one from
+NewGDual), panics, since the result would be silently truncated or
+differentiated along an unrelated variable. the checked operations
+(AddE, ...) return ErrContextMismatch and ErrOrderMismatch instead.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"sort"
+)
+
+type ContextOf[T Field] struct {
+	order int
+	seeds map[string]T
+}
+
+// Context is a context for GDual, of float64
+type Context = ContextOf[float64]
+
+// NewContext creates a context for GDual of the given order
+func NewContext(order int) *Context {
+	return NewContextOf[float64](order)
+}
+
+// NewContextOf creates a context for GDualOf[T] of the given order
+func NewContextOf[T Field](order int) *ContextOf[T] {
+	ctx := &ContextOf[T]{
+		order: order,
+		seeds: make(map[string]T),
+	}
+
+	return ctx
+}
+
+func (c *ContextOf[T]) Order() int {
+	return c.order
+}
+
+// Var declares the variable of the context, named by symbol and seeded
+// at seed. it returns ErrRedeclared if the symbol is already declared, and
+// ErrTooManyVariables if another one is.
+func (c *ContextOf[T]) Var(symbol string, seed T) (*GDualOf[T], error) {
+	if _, ok := c.seeds[symbol]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrRedeclared, symbol)
+	}
+
+	if len(c.seeds) > 0 {
+		return nil, fmt.Errorf("%w: can't declare %s as well", ErrTooManyVariables, symbol)
+	}
+	c.seeds[symbol] = seed
+
+	gdual := NewGDualOf(c.order, seed, true)
+	gdual.symbols = []string{symbol}
+	gdual.ctx = c
+
+	return gdual, nil
+}
+
+// Const creates a constant, which depends on no variables
+func (c *ContextOf[T]) Const(val T) *GDualOf[T] {
+	gdual := NewGDualOf(c.order, val, false)
+	gdual.ctx = c
+
+	return gdual
+}
+
+// Vars returns the sorted symbols of every declared variable
+func (c *ContextOf[T]) Vars() []string {
+	symbols := make([]string, 0, len(c.seeds))
+	for s := range c.seeds {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+// Seed returns the seed of a declared variable
+func (c *ContextOf[T]) Seed(symbol string) (T, bool) {
+	seed, ok := c.seeds[symbol]
+
+	return seed, ok
+}
+
+/*
+mix panics when g and inp can't be combined by an operation that doesn't
+return an error: values of two different contexts, or a value of a
+context with one of a different order. values from NewGDual belong to no
+context, and keep mixing with each other, truncated to the smaller order.
+*/
+func (g *GDualOf[T]) mix(inp *GDualOf[T]) {
+	if g.ctx == nil && inp.ctx == nil {
+		return
+	}
+
+	if err := g.check(inp); err != nil {
+		panic(err)
+	}
+}
+
+// Eval evaluates an expression at the seed of the declared variable
+func (c *ContextOf[T]) Eval(e *Expr) (*GDualOf[T], error) {
+	gdual, err := EvalOf(e, c.order, c.seeds)
+	if err != nil {
+		return nil, err
+	}
+	gdual.ctx = c
+
+	return gdual, nil
+}
@@ -0,0 +1,196 @@
+package gdual
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	order := 10
+	ctx := NewContext(order)
+
+	// f(3.0) = 4x^2 / (1 - x)^3, as in TestComplex
+	x, _ := ctx.Var("x", 3.0)
+	one := ctx.Const(1.0)
+	four := ctx.Const(4.0)
+	y := x.Pow(2).Mul(four).Div(one.Sub(x).Pow(3))
+
+	ex := NewGDual(order, 3.0, true)
+	expected := ex.Pow(2).Mul(NewGDual(order, 4.0, false)).Div(NewGDual(order, 1.0, false).Sub(ex).Pow(3))
+
+	if y.Order() != order {
+		t.Errorf("order mismatch on context: have %d want %d", y.Order(), order)
+	}
+
+	for k := 0; k < order; k++ {
+		if y.mat.get(k) != expected.mat.get(k) {
+			t.Errorf("value mismatch on context (col %d): have %v want %v", k, y.mat.get(k), expected.mat.get(k))
+		}
+	}
+
+	if have := y.Variables(); !reflect.DeepEqual(have, []string{"x"}) || !y.IsVariable() {
+		t.Errorf("value mismatch on variables: have %v want %v", have, []string{"x"})
+	}
+
+	if have := four.Mul(one).Variables(); len(have) != 0 || four.IsVariable() {
+		t.Errorf("value mismatch on constant variables: have %v want none", have)
+	}
+}
+
+func TestContextDependencies(t *testing.T) {
+	ctx := NewContext(4)
+	x, _ := ctx.Var("x", 1.0)
+	two := ctx.Const(2.0)
+
+	tests := []struct {
+		name     string
+		have     *GDual
+		expected []string
+	}{
+		{"unary", x.Sin().Exp(), []string{"x"}},
+		{"binary", two.Mul(x), []string{"x"}},
+		{"atan2", two.Atan2(x), []string{"x"}},
+		{"power", two.PowGDual(x.Add(two)), []string{"x"}},
+		{"constant", two.Sqrt().Mul(x), []string{"x"}},
+		{"anonymous", NewGDual(4, 1.0, true).Add(x), []string{"x"}},
+	}
+
+	for i, tt := range tests {
+		if have := tt.have.Variables(); !reflect.DeepEqual(have, tt.expected) {
+			t.Errorf("value mismatch on %s test %d: have %v want %v", tt.name, i, have, tt.expected)
+		}
+	}
+
+	if have := ctx.Vars(); !reflect.DeepEqual(have, []string{"x"}) {
+		t.Errorf("value mismatch on declared variables: have %v want %v", have, []string{"x"})
+	}
+
+	if seed, ok := ctx.Seed("x"); !ok || seed != 1.0 {
+		t.Errorf("value mismatch on seed: have %v want %v", seed, 1.0)
+	}
+}
+
+func TestContextEval(t *testing.T) {
+	order := 5
+	ctx := NewContext(order)
+	x, _ := ctx.Var("x", 2.0)
+
+	// the same expression, eagerly and as a graph
+	eager := x.Mul(x.Pow(2)).Add(x.Sin().Mul(x.Exp()))
+
+	ex := NewVariable("x")
+	lazy, err := ctx.Eval(ex.Mul(ex.Pow(2)).Add(ex.Sin().Mul(ex.Exp())))
+	if err != nil {
+		t.Fatalf("failed on context eval: %v", err)
+	}
+
+	for k := 0; k < order; k++ {
+		if !almostEqual(lazy.mat.get(k), eager.mat.get(k)) {
+			t.Errorf("value mismatch on context eval (col %d): have %v want %v",
+				k, lazy.mat.get(k), eager.mat.get(k))
+		}
+	}
+
+	if have := lazy.Variables(); !reflect.DeepEqual(have, []string{"x"}) {
+		t.Errorf("value mismatch on eval variables: have %v want %v", have, []string{"x"})
+	}
+
+	if have := lazy.Add(x).Order(); have != order {
+		t.Errorf("order mismatch on eval result: have %d want %d", have, order)
+	}
+
+	if _, err := ctx.Eval(NewVariable("w")); !errors.Is(err, ErrUnboundVariable) {
+		t.Errorf("error mismatch on context eval: have %v want %v", err, ErrUnboundVariable)
+	}
+
+	// complex contexts hold complex seeds
+	cctx := NewContextOf[complex128](order)
+	z, _ := cctx.Var("z", complex(1.0, 1.0))
+	if have := z.Exp().Value(); !complexClose(have, complex(1.4686939399158851, 2.2873552871788423)) {
+		t.Errorf("value mismatch on complex context: have %v", have)
+	}
+}
+
+func TestContextMismatch(t *testing.T) {
+	ctx := NewContext(4)
+	x, err := ctx.Var("x", 1.0)
+	if err != nil {
+		t.Fatalf("unexpected error on declaration: %v", err)
+	}
+
+	if _, err := ctx.Var("x", 2.0); !errors.Is(err, ErrRedeclared) {
+		t.Errorf("error mismatch on redeclaration: have %v want %v", err, ErrRedeclared)
+	}
+
+	if seed, _ := ctx.Seed("x"); seed != 1.0 {
+		t.Errorf("value mismatch on redeclared seed: have %v want %v", seed, 1.0)
+	}
+
+	if _, err := ctx.Var("y", 2.0); !errors.Is(err, ErrTooManyVariables) {
+		t.Errorf("error mismatch on second variable: have %v want %v", err, ErrTooManyVariables)
+	}
+
+	if _, ok := ctx.Seed("y"); ok {
+		t.Errorf("value mismatch on second variable: declared")
+	}
+
+	other := NewContext(4)
+	y, _ := other.Var("y", 2.0)
+
+	tests := []struct {
+		name     string
+		have     *GDual
+		expected error
+	}{
+		{"same context", ctx.Const(2.0), nil},
+		{"no context", NewGDual(4, 2.0, false), nil},
+		{"other context", y, ErrContextMismatch},
+		{"other context result", y.Sin().Mul(other.Const(2.0)), ErrContextMismatch},
+		{"other order", NewGDual(5, 2.0, false), ErrOrderMismatch},
+		{"other context order", NewContext(5).Const(2.0), ErrOrderMismatch},
+	}
+
+	for i, tt := range tests {
+		ops := []func(*GDual) (*GDual, error){x.AddE, x.SubE, x.MulE, x.DivE, x.Exp().AddE}
+		for _, op := range ops {
+			if _, err := op(tt.have); !errors.Is(err, tt.expected) {
+				t.Errorf("error mismatch on %s test %d: have %v want %v", tt.name, i, err, tt.expected)
+			}
+		}
+
+		// the unchecked operations panic instead, either way around
+		plain := []func(a, b *GDual){
+			func(a, b *GDual) { a.Add(b) },
+			func(a, b *GDual) { a.Mul(b) },
+			func(a, b *GDual) { a.Atan2(b) },
+			func(a, b *GDual) { a.PowGDual(b) },
+			func(a, b *GDual) { new(GDual).SetSub(a, b) },
+			func(a, b *GDual) { new(GDual).SetDiv(a, b) },
+			func(a, b *GDual) { a.Compose(b) },
+		}
+		for j, op := range plain {
+			for _, args := range [][2]*GDual{{x, tt.have}, {tt.have, x}} {
+				if panicked := mixPanics(op, args[0], args[1]); panicked != (tt.expected != nil) {
+					t.Errorf("panic mismatch on %s test %d (op %d): have %v want %v",
+						tt.name, i, j, panicked, tt.expected != nil)
+				}
+			}
+		}
+	}
+
+	// values without a context still mix across orders, as before
+	if have := NewGDual(4, 1.0, true).Add(NewGDual(5, 2.0, false)).Order(); have != 4 {
+		t.Errorf("order mismatch on anonymous values: have %d want %d", have, 4)
+	}
+}
+
+// mixPanics reports whether op(a, b) panics
+func mixPanics(op func(a, b *GDual), a, b *GDual) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	op(a, b)
+
+	return false
+}
@@ -0,0 +1,308 @@
+/*
+
+destination operations for GDual.
+
+like the matrices (see matrix.go), every operation on a GDual also has
+a form that writes its result into the receiver instead of returning a
+new GDual, in the style of math/big:
+
+	z := new(GDual)
+	for ... {
+		// z = 4x^2 / (1 - x)^3, without allocating once z has grown
+		z.SetPow(x, 2).SetMul(z, four).SetDiv(z, w.SetPow(w.SetSub(one, x), 3))
+	}
+
+the receiver can be any of the inputs, and the zero GDual is ready to
+use as a receiver. its matrix is reused as long as the receiver and
+every input use the default backend. with any other backend, the result
+is computed by the allocating operation and stored in the receiver.
+
+keeping track of Context variables only allocates when the inputs
+depend on different ones.
+
+*/
+
+package gdual
+
+// toeplitzOf returns the matrix of the default backend
+func toeplitzOf[T Field](mat series[T]) (*UpperTriToeplitzOf[T], bool) {
+	b, ok := mat.(backend[T, *UpperTriToeplitzOf[T]])
+
+	return b.mat, ok
+}
+
+// destination returns the matrix to write the result into, when z and
+// every input use the default backend. a zero GDual gets one here. the
+// inputs of a binary operation must mix, see Context.
+func (z *GDualOf[T]) destination(inputs ...*GDualOf[T]) (*UpperTriToeplitzOf[T], bool) {
+	if len(inputs) == 2 {
+		inputs[0].mix(inputs[1])
+	}
+
+	for _, inp := range inputs {
+		if _, ok := toeplitzOf(inp.mat); !ok {
+			return nil, false
+		}
+	}
+
+	if z.mat == nil {
+		mat := NewUpperTriToeplitzOf[T](0)
+		z.mat = backend[T, *UpperTriToeplitzOf[T]]{
+			mat:         mat,
+			constructor: NewUpperTriToeplitzOf[T],
+		}
+
+		return mat, true
+	}
+
+	return toeplitzOf(z.mat)
+}
+
+// inherit sets whether z is a variable, the symbols it depends on, and
+// its context, from the inputs of an operation. y is nil for unary operations.
+func (z *GDualOf[T]) inherit(x, y *GDualOf[T]) {
+	variable, symbols, ctx := x.variable, x.symbols, x.ctx
+	if y != nil {
+		variable = variable || y.variable
+		symbols = mergeSymbols(symbols, y.symbols)
+		if ctx == nil {
+			ctx = y.ctx
+		}
+	}
+
+	z.variable = variable
+	z.symbols = symbols
+	z.ctx = ctx
+}
+
+// the union of two sorted sets of symbols, which only allocates when they differ.
+// symbols are never modified once set, so the result can share either input.
+func mergeSymbols(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+
+	if len(a) == 0 {
+		return b
+	}
+
+	if len(a) == len(b) {
+		same := true
+		for i := range a {
+			if a[i] != b[i] {
+				same = false
+				break
+			}
+		}
+
+		if same {
+			return a
+		}
+	}
+
+	return unionSymbols(a, b)
+}
+
+// sets z to fn(x), where set is the destination form of fn on the matrices
+func (z *GDualOf[T]) setUnary(x *GDualOf[T], set func(z, x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(x); ok {
+		set(mat, x.mat.toeplitz())
+	} else {
+		z.mat = apply(x.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+			return set(new(UpperTriToeplitzOf[T]), m).detach()
+		})
+	}
+	z.inherit(x, nil)
+
+	return z
+}
+
+// Set sets z to a copy of x
+func (z *GDualOf[T]) Set(x *GDualOf[T]) *GDualOf[T] {
+	if z == x {
+		return z
+	}
+
+	if mat, ok := z.destination(x); ok {
+		mat.Set(x.mat.toeplitz())
+	} else {
+		z.mat = x.mat.from(x.mat.toeplitz().Copy())
+	}
+	z.inherit(x, nil)
+
+	return z
+}
+
+/* arithmetic */
+
+func (z *GDualOf[T]) SetAdd(x, y *GDualOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(x, y); ok {
+		mat.SetAdd(x.mat.toeplitz(), y.mat.toeplitz())
+	} else {
+		z.mat = x.mat.add(y.mat)
+	}
+	z.inherit(x, y)
+
+	return z
+}
+
+func (z *GDualOf[T]) SetSub(x, y *GDualOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(x, y); ok {
+		mat.SetSub(x.mat.toeplitz(), y.mat.toeplitz())
+	} else {
+		z.mat = x.mat.sub(y.mat)
+	}
+	z.inherit(x, y)
+
+	return z
+}
+
+func (z *GDualOf[T]) SetMul(x, y *GDualOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(x, y); ok {
+		mat.SetMul(x.mat.toeplitz(), y.mat.toeplitz())
+	} else {
+		z.mat = x.mat.mul(y.mat)
+	}
+	z.inherit(x, y)
+
+	return z
+}
+
+func (z *GDualOf[T]) SetDiv(x, y *GDualOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(x, y); ok {
+		mat.SetDiv(x.mat.toeplitz(), y.mat.toeplitz())
+	} else {
+		z.mat = x.mat.div(y.mat)
+	}
+	z.inherit(x, y)
+
+	return z
+}
+
+func (z *GDualOf[T]) SetInv(x *GDualOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(x); ok {
+		mat.SetInv(x.mat.toeplitz())
+	} else {
+		z.mat = x.mat.inv()
+	}
+	z.inherit(x, nil)
+
+	return z
+}
+
+func (z *GDualOf[T]) SetPow(x *GDualOf[T], n int) *GDualOf[T] {
+	if mat, ok := z.destination(x); ok {
+		mat.SetPow(x.mat.toeplitz(), n)
+	} else {
+		z.mat = x.mat.pow(n)
+	}
+	z.inherit(x, nil)
+
+	return z
+}
+
+/* elementary functions */
+
+func (z *GDualOf[T]) SetExp(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetExp)
+}
+
+func (z *GDualOf[T]) SetLog(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetLog)
+}
+
+func (z *GDualOf[T]) SetSqrt(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetSqrt)
+}
+
+func (z *GDualOf[T]) SetSin(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetSin)
+}
+
+func (z *GDualOf[T]) SetCos(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetCos)
+}
+
+func (z *GDualOf[T]) SetTan(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetTan)
+}
+
+func (z *GDualOf[T]) SetSinh(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetSinh)
+}
+
+func (z *GDualOf[T]) SetCosh(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetCosh)
+}
+
+func (z *GDualOf[T]) SetTanh(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetTanh)
+}
+
+/* inverse functions */
+
+func (z *GDualOf[T]) SetAsin(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetAsin)
+}
+
+func (z *GDualOf[T]) SetAcos(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetAcos)
+}
+
+func (z *GDualOf[T]) SetAtan(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetAtan)
+}
+
+// SetAtan2 sets z to atan(y / x), using the signs of both to pick the quadrant
+func (z *GDualOf[T]) SetAtan2(y, x *GDualOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(y, x); ok {
+		mat.SetAtan2(y.mat.toeplitz(), x.mat.toeplitz())
+	} else {
+		z.mat = apply(y.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+			return m.Atan2(x.mat.toeplitz())
+		})
+	}
+	z.inherit(y, x)
+
+	return z
+}
+
+func (z *GDualOf[T]) SetAsinh(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetAsinh)
+}
+
+func (z *GDualOf[T]) SetAcosh(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetAcosh)
+}
+
+func (z *GDualOf[T]) SetAtanh(x *GDualOf[T]) *GDualOf[T] {
+	return z.setUnary(x, (*UpperTriToeplitzOf[T]).SetAtanh)
+}
+
+/* powers */
+
+func (z *GDualOf[T]) SetPowReal(x *GDualOf[T], p float64) *GDualOf[T] {
+	if mat, ok := z.destination(x); ok {
+		mat.SetPowReal(x.mat.toeplitz(), p)
+	} else {
+		z.mat = apply(x.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+			return m.PowReal(p)
+		})
+	}
+	z.inherit(x, nil)
+
+	return z
+}
+
+func (z *GDualOf[T]) SetPowGDual(x, e *GDualOf[T]) *GDualOf[T] {
+	if mat, ok := z.destination(x, e); ok {
+		mat.SetPowToeplitz(x.mat.toeplitz(), e.mat.toeplitz())
+	} else {
+		z.mat = apply(x.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+			return m.PowToeplitz(e.mat.toeplitz())
+		})
+	}
+	z.inherit(x, e)
+
+	return z
+}
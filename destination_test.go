@@ -0,0 +1,185 @@
+package gdual
+
+import (
+	"reflect"
+	"testing"
+)
+
+// every destination operation, next to the allocating operation it must match
+var destinationTests = []struct {
+	name string
+	set  func(z, x, y *GDual) *GDual
+	fn   func(x, y *GDual) *GDual
+}{
+	{"add", (*GDual).SetAdd, (*GDual).Add},
+	{"sub", (*GDual).SetSub, (*GDual).Sub},
+	{"mul", (*GDual).SetMul, (*GDual).Mul},
+	{"div", (*GDual).SetDiv, (*GDual).Div},
+	{"atan2", (*GDual).SetAtan2, (*GDual).Atan2},
+	{"pow gdual", (*GDual).SetPowGDual, (*GDual).PowGDual},
+	{"inv", func(z, x, _ *GDual) *GDual { return z.SetInv(x) },
+		func(x, _ *GDual) *GDual { return x.Inv() }},
+	{"pow", func(z, x, _ *GDual) *GDual { return z.SetPow(x, 5) },
+		func(x, _ *GDual) *GDual { return x.Pow(5) }},
+	{"pow negative", func(z, x, _ *GDual) *GDual { return z.SetPow(x, -3) },
+		func(x, _ *GDual) *GDual { return x.Pow(-3) }},
+	{"pow real", func(z, x, _ *GDual) *GDual { return z.SetPowReal(x, 2.5) },
+		func(x, _ *GDual) *GDual { return x.PowReal(2.5) }},
+	{"exp", func(z, x, _ *GDual) *GDual { return z.SetExp(x) },
+		func(x, _ *GDual) *GDual { return x.Exp() }},
+	{"log", func(z, x, _ *GDual) *GDual { return z.SetLog(x) },
+		func(x, _ *GDual) *GDual { return x.Log() }},
+	{"sqrt", func(z, x, _ *GDual) *GDual { return z.SetSqrt(x) },
+		func(x, _ *GDual) *GDual { return x.Sqrt() }},
+	{"sin", func(z, x, _ *GDual) *GDual { return z.SetSin(x) },
+		func(x, _ *GDual) *GDual { return x.Sin() }},
+	{"cos", func(z, x, _ *GDual) *GDual { return z.SetCos(x) },
+		func(x, _ *GDual) *GDual { return x.Cos() }},
+	{"tan", func(z, x, _ *GDual) *GDual { return z.SetTan(x) },
+		func(x, _ *GDual) *GDual { return x.Tan() }},
+	{"sinh", func(z, x, _ *GDual) *GDual { return z.SetSinh(x) },
+		func(x, _ *GDual) *GDual { return x.Sinh() }},
+	{"cosh", func(z, x, _ *GDual) *GDual { return z.SetCosh(x) },
+		func(x, _ *GDual) *GDual { return x.Cosh() }},
+	{"tanh", func(z, x, _ *GDual) *GDual { return z.SetTanh(x) },
+		func(x, _ *GDual) *GDual { return x.Tanh() }},
+	{"asin", func(z, x, _ *GDual) *GDual { return z.SetAsin(x) },
+		func(x, _ *GDual) *GDual { return x.Asin() }},
+	{"acos", func(z, x, _ *GDual) *GDual { return z.SetAcos(x) },
+		func(x, _ *GDual) *GDual { return x.Acos() }},
+	{"atan", func(z, x, _ *GDual) *GDual { return z.SetAtan(x) },
+		func(x, _ *GDual) *GDual { return x.Atan() }},
+	{"asinh", func(z, x, _ *GDual) *GDual { return z.SetAsinh(x) },
+		func(x, _ *GDual) *GDual { return x.Asinh() }},
+	{"acosh", func(z, x, _ *GDual) *GDual { return z.SetAcosh(x) },
+		func(x, _ *GDual) *GDual { return x.Acosh() }},
+	{"atanh", func(z, x, _ *GDual) *GDual { return z.SetAtanh(x) },
+		func(x, _ *GDual) *GDual { return x.Atanh() }},
+}
+
+// seeds inside the domain of every function, acosh included
+func destinationInputs(order int) (*GDual, *GDual) {
+	x := NewGDual(order, 0.6, true).Sin().AddScalar(0.6)
+	y := NewGDual(order, 0.6, true).Exp()
+
+	return x, y
+}
+
+func checkDestination(t *testing.T, name string, i int, have, want *GDual) {
+	if have.Order() != want.Order() {
+		t.Fatalf("order mismatch on %s test %d: have %d want %d", name, i, have.Order(), want.Order())
+	}
+
+	for k := 0; k < want.Order(); k++ {
+		if have.mat.get(k) != want.mat.get(k) && !(isNaN(have.mat.get(k)) && isNaN(want.mat.get(k))) {
+			t.Errorf("value mismatch on %s test %d (col %d): have %v want %v",
+				name, i, k, have.mat.get(k), want.mat.get(k))
+		}
+	}
+
+	if have.IsVariable() != want.IsVariable() || !reflect.DeepEqual(have.Variables(), want.Variables()) {
+		t.Errorf("variable mismatch on %s test %d: have %v want %v", name, i, have.Variables(), want.Variables())
+	}
+}
+
+func TestDestination(t *testing.T) {
+	order := 10
+
+	for i, tt := range destinationTests {
+		x, y := destinationInputs(order)
+		want := tt.fn(x, y)
+
+		// into a zero GDual, and again into the same one
+		z := new(GDual)
+		checkDestination(t, tt.name, i, tt.set(z, x, y), want)
+		checkDestination(t, tt.name, i, tt.set(z, x, y), want)
+
+		// with the receiver as the first input, and then as the second
+		x2, y2 := destinationInputs(order)
+		checkDestination(t, tt.name+" (z = x)", i, tt.set(x2, x2, y2), want)
+
+		x3, y3 := destinationInputs(order)
+		checkDestination(t, tt.name+" (z = y)", i, tt.set(y3, x3, y3), want)
+
+		// and as both
+		x4, _ := destinationInputs(order)
+		checkDestination(t, tt.name+" (z = x = y)", i, tt.set(x4, x4, x4), tt.fn(x, x))
+
+		// above the FFT threshold
+		fx, fy := destinationInputs(fftThreshold)
+		checkDestination(t, tt.name+" (fft)", i, tt.set(fx, fx, fy), tt.fn(destinationInputs(fftThreshold)))
+	}
+}
+
+func TestDestinationBackends(t *testing.T) {
+	order := 6
+
+	// a standard matrix backend falls back to the allocating operations
+	for i, tt := range destinationTests {
+		x := NewGDualFrom(NewMatrix, order, 0.6, true)
+		y := NewGDualFrom(NewMatrix, order, 1.3, true)
+		want := tt.fn(x, y)
+
+		z := NewGDual(order, 0.0, false)
+		have := tt.set(z, x, y)
+
+		for k := 0; k < order; k++ {
+			if !almostEqual(have.mat.get(k), want.mat.get(k)) && !isNaN(want.mat.get(k)) {
+				t.Errorf("value mismatch on %s test %d (col %d): have %v want %v",
+					tt.name, i, k, have.mat.get(k), want.mat.get(k))
+			}
+		}
+	}
+
+	// f(3.0) = 4x^2 / (1 - x)^3, as in TestComplex
+	expected := []float64{
+		-4.5, 3.75, -2.75, 1.875,
+		-1.21875, 0.765625, -0.46875,
+		0.28125, -0.166015625,
+	}
+
+	x := NewGDual(10, 3.0, true)
+	one := NewGDual(10, 1.0, false)
+	four := NewGDual(10, 4.0, false)
+
+	var z, w GDual
+	z.SetPow(x, 2).SetMul(&z, four).SetDiv(&z, w.SetPow(w.SetSub(one, x), 3))
+	for i := 0; i < len(expected); i++ {
+		if z.mat.get(i) != expected[i] {
+			t.Errorf("value mismatch on destination complex test (col %d): have %v want %v",
+				i, z.mat.get(i), expected[i])
+		}
+	}
+
+	// a constant receiver becomes a variable, and the other way around
+	if !z.IsVariable() || w.Set(one).IsVariable() {
+		t.Errorf("variable mismatch on destination: have %v and %v", z.IsVariable(), w.IsVariable())
+	}
+}
+
+func TestDestinationAllocs(t *testing.T) {
+	order := 16
+	x, y := destinationInputs(order)
+
+	for i, tt := range destinationTests {
+		z := new(GDual)
+		allocs := testing.AllocsPerRun(100, func() {
+			tt.set(z, x, y)
+		})
+
+		if allocs != 0 {
+			t.Errorf("allocation mismatch on %s test %d: have %v want 0", tt.name, i, allocs)
+		}
+	}
+
+	// the matrices on their own, with the receiver as an input
+	m := importUpperTriToeplitz(randFloats(0.5, 1, order))
+	z := new(UpperTriToeplitz)
+	allocs := testing.AllocsPerRun(100, func() {
+		z.SetMul(m, m).SetDiv(z, m).SetExp(z).SetLog(z).SetPowReal(z, 1.5)
+	})
+
+	if allocs != 0 {
+		t.Errorf("allocation mismatch on matrices: have %v want 0", allocs)
+	}
+}
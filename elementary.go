@@ -0,0 +1,624 @@
+/*
+
+elementary functions for upper triangular Toeplitz matrices.
+
+the first row of our matrix holds the normalized Taylor coefficients
+a_k = f^(k)(x0) / k! of some function, so applying an elementary
+function to the matrix is the same as composing that function with
+the truncated power series. instead of expanding the composition
+directly, each function here satisfies a simple differential equation
+(exp' = exp * a', log' = a' / a, ...), which turns into a recurrence
+on the coefficients that only needs the lower order terms already
+computed. every function is O(n^2), where n is the order of the matrix.
+
+see Knuth, TAOCP vol. 2, section 4.7 for the general approach.
+
+*/
+
+package gdual
+
+import (
+	"math"
+)
+
+/* exponential and logarithm */
+
+func (m *UpperTriToeplitzOf[T]) Exp() *UpperTriToeplitzOf[T] {
+	return m.result().SetExp(m).detach()
+}
+
+// b' = b * a'  =>  b_k = 1/k * Σ j*a_j*b_{k-j}
+func (z *UpperTriToeplitzOf[T]) SetExp(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	da := z.temp(0, order).setDerivative(x)
+	a := x.get(0)
+
+	z.resize(order)
+	if order == 0 {
+		return z
+	}
+
+	z.set(0, scalarExp(a))
+	for k := 1; k < order; k++ {
+		var sum T
+		for j := 1; j <= k; j++ {
+			sum += da.get(j-1) * z.get(k-j)
+		}
+		z.set(k, sum/fromInt[T](k))
+	}
+
+	return z
+}
+
+func (m *UpperTriToeplitzOf[T]) Log() *UpperTriToeplitzOf[T] {
+	return m.result().SetLog(m).detach()
+}
+
+// a * b' = a'  =>  b_k = (a_k - 1/k * Σ j*b_j*a_{k-j}) / a_0
+func (z *UpperTriToeplitzOf[T]) SetLog(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	x = z.input(x, 0)
+
+	// db holds the derivative of z, as far as it's known
+	db := z.temp(1, x.order)
+
+	z.resize(x.order)
+	if x.order == 0 {
+		return z
+	}
+
+	a := x.get(0)
+	z.set(0, scalarLog(a))
+	for k := 1; k < x.order; k++ {
+		var sum T
+		for j := 1; j < k; j++ {
+			sum += db.get(j-1) * x.get(k-j)
+		}
+		fk := fromInt[T](k)
+		z.set(k, (x.get(k)-sum/fk)/a)
+		db.set(k-1, fk*z.get(k))
+	}
+
+	return z
+}
+
+/* roots */
+
+func (m *UpperTriToeplitzOf[T]) Sqrt() *UpperTriToeplitzOf[T] {
+	return m.result().SetSqrt(m).detach()
+}
+
+// b * b = a  =>  b_k = (a_k - Σ b_j*b_{k-j}) / 2b_0
+func (z *UpperTriToeplitzOf[T]) SetSqrt(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	return z.sqrtWith(x, scalarSqrt(x.get(0)))
+}
+
+// the square root of the series with b as its leading coefficient, which
+// picks the branch (the sign, for real series) of the whole series. a_k is
+// read before b_k is written, so z can be x.
+func (z *UpperTriToeplitzOf[T]) sqrtWith(x *UpperTriToeplitzOf[T], b T) *UpperTriToeplitzOf[T] {
+	z.resize(x.order)
+	if x.order == 0 {
+		return z
+	}
+
+	z.set(0, b)
+	for k := 1; k < x.order; k++ {
+		var sum T
+		for j := 1; j < k; j++ {
+			sum += z.get(j) * z.get(k-j)
+		}
+		z.set(k, (x.get(k)-sum)/(2*b))
+	}
+
+	return z
+}
+
+/* trigonometric functions */
+
+// s' = c * a' and c' = -s * a', so both series are built together.
+// neither sin nor cos can be da, but either can be x.
+func setSinCos[T Field](sin, cos, da, x *UpperTriToeplitzOf[T]) {
+	order := x.order
+	da.setDerivative(x)
+	a := x.get(0)
+
+	sin.resize(order)
+	cos.resize(order)
+	if order == 0 {
+		return
+	}
+
+	sin.set(0, scalarSin(a))
+	cos.set(0, scalarCos(a))
+	for k := 1; k < order; k++ {
+		var sumSin, sumCos T
+		for j := 1; j <= k; j++ {
+			ja := da.get(j - 1)
+			sumSin += ja * cos.get(k-j)
+			sumCos += ja * sin.get(k-j)
+		}
+		fk := fromInt[T](k)
+		sin.set(k, sumSin/fk)
+		cos.set(k, -sumCos/fk)
+	}
+}
+
+func (m *UpperTriToeplitzOf[T]) Sin() *UpperTriToeplitzOf[T] {
+	return m.result().SetSin(m).detach()
+}
+
+func (z *UpperTriToeplitzOf[T]) SetSin(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	setSinCos(z, z.temp(0, x.order), z.temp(1, x.order), x)
+
+	return z
+}
+
+func (m *UpperTriToeplitzOf[T]) Cos() *UpperTriToeplitzOf[T] {
+	return m.result().SetCos(m).detach()
+}
+
+func (z *UpperTriToeplitzOf[T]) SetCos(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	setSinCos(z.temp(0, x.order), z, z.temp(1, x.order), x)
+
+	return z
+}
+
+func (m *UpperTriToeplitzOf[T]) Tan() *UpperTriToeplitzOf[T] {
+	return m.result().SetTan(m).detach()
+}
+
+// t' = (1 + t^2) * a', where u = 1 + t^2 is built alongside t
+func (z *UpperTriToeplitzOf[T]) SetTan(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	u := z.temp(0, order)
+	da := z.temp(1, order).setDerivative(x)
+	a := x.get(0)
+
+	z.resize(order)
+	if order == 0 {
+		return z
+	}
+
+	t := scalarTan(a)
+	z.set(0, t)
+	u.set(0, 1+t*t)
+	for k := 1; k < order; k++ {
+		var sum T
+		for j := 1; j <= k; j++ {
+			sum += da.get(j-1) * u.get(k-j)
+		}
+		z.set(k, sum/fromInt[T](k))
+
+		var square T
+		for j := 0; j <= k; j++ {
+			square += z.get(j) * z.get(k-j)
+		}
+		u.set(k, square)
+	}
+
+	return z
+}
+
+/* hyperbolic functions */
+
+// s' = c * a' and c' = s * a', so both series are built together.
+// neither sinh nor cosh can be da, but either can be x.
+func setSinhCosh[T Field](sinh, cosh, da, x *UpperTriToeplitzOf[T]) {
+	order := x.order
+	da.setDerivative(x)
+	a := x.get(0)
+
+	sinh.resize(order)
+	cosh.resize(order)
+	if order == 0 {
+		return
+	}
+
+	sinh.set(0, scalarSinh(a))
+	cosh.set(0, scalarCosh(a))
+	for k := 1; k < order; k++ {
+		var sumSinh, sumCosh T
+		for j := 1; j <= k; j++ {
+			ja := da.get(j - 1)
+			sumSinh += ja * cosh.get(k-j)
+			sumCosh += ja * sinh.get(k-j)
+		}
+		fk := fromInt[T](k)
+		sinh.set(k, sumSinh/fk)
+		cosh.set(k, sumCosh/fk)
+	}
+}
+
+func (m *UpperTriToeplitzOf[T]) Sinh() *UpperTriToeplitzOf[T] {
+	return m.result().SetSinh(m).detach()
+}
+
+func (z *UpperTriToeplitzOf[T]) SetSinh(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	setSinhCosh(z, z.temp(0, x.order), z.temp(1, x.order), x)
+
+	return z
+}
+
+func (m *UpperTriToeplitzOf[T]) Cosh() *UpperTriToeplitzOf[T] {
+	return m.result().SetCosh(m).detach()
+}
+
+func (z *UpperTriToeplitzOf[T]) SetCosh(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	setSinhCosh(z.temp(0, x.order), z, z.temp(1, x.order), x)
+
+	return z
+}
+
+func (m *UpperTriToeplitzOf[T]) Tanh() *UpperTriToeplitzOf[T] {
+	return m.result().SetTanh(m).detach()
+}
+
+// t' = (1 - t^2) * a', where u = 1 - t^2 is built alongside t
+func (z *UpperTriToeplitzOf[T]) SetTanh(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	u := z.temp(0, order)
+	da := z.temp(1, order).setDerivative(x)
+	a := x.get(0)
+
+	z.resize(order)
+	if order == 0 {
+		return z
+	}
+
+	t := scalarTanh(a)
+	z.set(0, t)
+	u.set(0, 1-t*t)
+	for k := 1; k < order; k++ {
+		var sum T
+		for j := 1; j <= k; j++ {
+			sum += da.get(j-1) * u.get(k-j)
+		}
+		z.set(k, sum/fromInt[T](k))
+
+		var square T
+		for j := 0; j <= k; j++ {
+			square += z.get(j) * z.get(k-j)
+		}
+		u.set(k, -square)
+	}
+
+	return z
+}
+
+/* inverse functions */
+
+// the derivative of the series, truncated to the same order. b_{k-1} is
+// written after a_k is read, so z can be x.
+func (z *UpperTriToeplitzOf[T]) setDerivative(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	z.resize(x.order)
+	for k := 1; k < x.order; k++ {
+		z.set(k-1, fromInt[T](k)*x.get(k))
+	}
+	z.set(x.order-1, 0)
+
+	return z
+}
+
+// whether a real seed falls outside of [lo, hi], or (lo, hi) when open.
+// a complex seed never does, since every function continues into the
+// complex plane (past its branch cuts).
+func outside[T Field](a T, lo, hi float64, open bool) bool {
+	if isComplex[T]() {
+		return false
+	}
+
+	x := realPart(a)
+	if open {
+		return x <= lo || x >= hi
+	}
+
+	return x < lo || x > hi
+}
+
+// a series of NaN, used when the seed falls outside the domain of a function
+func (z *UpperTriToeplitzOf[T]) setNaN(order int) *UpperTriToeplitzOf[T] {
+	z.resize(order)
+	z.Reset(fromFloat[T](math.NaN()))
+
+	return z
+}
+
+// the constant series 1, as the i-th scratch matrix of z
+func (z *UpperTriToeplitzOf[T]) one(i, order int) *UpperTriToeplitzOf[T] {
+	one := z.temp(i, order)
+	one.Fill(0, 1.0)
+
+	return one
+}
+
+/*
+the derivatives of asin, acos, asinh and acosh all have a square root,
+which has two branches in the complex plane. the principal one doesn't
+always match the branch of the function itself (acosh in the left half
+plane, or any seed on a branch cut), so the root is picked from the
+value of the function instead: asin' = 1 / cos(asin(a)), and so on.
+real square roots only have the one branch.
+*/
+func (z *UpperTriToeplitzOf[T]) sqrtOnBranch(x *UpperTriToeplitzOf[T], root T) *UpperTriToeplitzOf[T] {
+	if !isComplex[T]() {
+		return z.SetSqrt(x)
+	}
+
+	return z.sqrtWith(x, root)
+}
+
+/*
+every inverse function has a derivative of the form b' = p / u,
+where p and u are series we already know. multiplying through
+gives u * b' = p, and matching the coefficient of x^{k-1} on
+both sides gives:
+
+b_k = (p_{k-1} - Σ j*b_j*u_{k-j}) / (k*u_0)
+
+where b_0 is the value of the function at the seed. db is scratch
+space for the derivative of b, and none of p, u and db can be z.
+*/
+func (z *UpperTriToeplitzOf[T]) integrateQuotient(b0 T, p, u, db *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	z.resize(u.order)
+	if u.order == 0 {
+		return z
+	}
+
+	// db holds the derivative of z, as far as it's known
+	db.resize(u.order)
+	db.Reset(0)
+
+	z.set(0, b0)
+	for k := 1; k < u.order; k++ {
+		var sum T
+		for j := 1; j < k; j++ {
+			sum += db.get(j-1) * u.get(k-j)
+		}
+		fk := fromInt[T](k)
+		z.set(k, (p.get(k-1)-sum)/(fk*u.get(0)))
+		db.set(k-1, fk*z.get(k))
+	}
+
+	return z
+}
+
+/*
+the inverse functions below read all of x into their scratch matrices
+(the square, the derivative, ...) before the recurrence writes to z,
+so z can be x.
+*/
+
+func (m *UpperTriToeplitzOf[T]) Asin() *UpperTriToeplitzOf[T] {
+	return m.result().SetAsin(m).detach()
+}
+
+// b' = a' / sqrt(1 - a^2), defined for a_0 in [-1, 1], where only the value exists at the ends
+func (z *UpperTriToeplitzOf[T]) SetAsin(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	a := x.get(0)
+	if outside(a, -1, 1, order > 1) {
+		return z.setNaN(order)
+	}
+
+	b := scalarAsin(a)
+
+	u := z.temp(0, order).SetSub(z.one(1, order), z.temp(2, order).SetMul(x, x))
+	u.sqrtOnBranch(u, scalarCos(b))
+
+	return z.integrateQuotient(b, z.temp(1, order).setDerivative(x), u, z.temp(2, order))
+}
+
+func (m *UpperTriToeplitzOf[T]) Acos() *UpperTriToeplitzOf[T] {
+	return m.result().SetAcos(m).detach()
+}
+
+// b' = -a' / sqrt(1 - a^2), defined for a_0 in [-1, 1], where only the value exists at the ends
+func (z *UpperTriToeplitzOf[T]) SetAcos(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	a := x.get(0)
+	if outside(a, -1, 1, order > 1) {
+		return z.setNaN(order)
+	}
+
+	b := scalarAcos(a)
+
+	u := z.temp(0, order).SetSub(z.one(1, order), z.temp(2, order).SetMul(x, x))
+	u.sqrtOnBranch(u, scalarSin(b))
+	u.ElementMul(-1.0)
+
+	return z.integrateQuotient(b, z.temp(1, order).setDerivative(x), u, z.temp(2, order))
+}
+
+func (m *UpperTriToeplitzOf[T]) Atan() *UpperTriToeplitzOf[T] {
+	return m.result().SetAtan(m).detach()
+}
+
+// b' = a' / (1 + a^2)
+func (z *UpperTriToeplitzOf[T]) SetAtan(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	b := scalarAtan(x.get(0))
+
+	u := z.temp(0, order).SetAdd(z.one(1, order), z.temp(2, order).SetMul(x, x))
+
+	return z.integrateQuotient(b, z.temp(1, order).setDerivative(x), u, z.temp(2, order))
+}
+
+// Atan2 returns atan(m / x), using the signs of both to pick the quadrant
+func (m *UpperTriToeplitzOf[T]) Atan2(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	return m.result().SetAtan2(m, x).detach()
+}
+
+// b' = (x*y' - y*x') / (x^2 + y^2)
+func (z *UpperTriToeplitzOf[T]) SetAtan2(y, x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	b := scalarAtan2(y.get(0), x.get(0))
+
+	tmp := z.temp(0, x.order).setDerivative(x)
+	p := z.temp(1, y.order).setDerivative(y)
+	p.SetMul(x, p)
+	tmp.SetMul(y, tmp)
+	p.SetSub(p, tmp)
+
+	u := z.temp(2, x.order).SetMul(x, x)
+	tmp.SetMul(y, y)
+	u.SetAdd(u, tmp)
+
+	return z.integrateQuotient(b, p, u, tmp)
+}
+
+func (m *UpperTriToeplitzOf[T]) Asinh() *UpperTriToeplitzOf[T] {
+	return m.result().SetAsinh(m).detach()
+}
+
+// b' = a' / sqrt(a^2 + 1)
+func (z *UpperTriToeplitzOf[T]) SetAsinh(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	b := scalarAsinh(x.get(0))
+
+	u := z.temp(0, order).SetMul(x, x)
+	u.SetAdd(u, z.one(1, order))
+	u.sqrtOnBranch(u, scalarCosh(b))
+
+	return z.integrateQuotient(b, z.temp(1, order).setDerivative(x), u, z.temp(2, order))
+}
+
+func (m *UpperTriToeplitzOf[T]) Acosh() *UpperTriToeplitzOf[T] {
+	return m.result().SetAcosh(m).detach()
+}
+
+// b' = a' / sqrt(a^2 - 1), defined for a_0 in [1, inf), where only the value exists at 1
+func (z *UpperTriToeplitzOf[T]) SetAcosh(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	a := x.get(0)
+	if outside(a, 1, math.Inf(1), order > 1) {
+		return z.setNaN(order)
+	}
+
+	b := scalarAcosh(a)
+
+	u := z.temp(0, order).SetMul(x, x)
+	u.SetSub(u, z.one(1, order))
+	u.sqrtOnBranch(u, scalarSinh(b))
+
+	return z.integrateQuotient(b, z.temp(1, order).setDerivative(x), u, z.temp(2, order))
+}
+
+func (m *UpperTriToeplitzOf[T]) Atanh() *UpperTriToeplitzOf[T] {
+	return m.result().SetAtanh(m).detach()
+}
+
+// b' = a' / (1 - a^2), defined for a_0 in (-1, 1)
+func (z *UpperTriToeplitzOf[T]) SetAtanh(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := x.order
+	a := x.get(0)
+	if outside(a, -1, 1, true) {
+		return z.setNaN(order)
+	}
+
+	b := scalarAtanh(a)
+
+	u := z.temp(0, order).SetSub(z.one(1, order), z.temp(2, order).SetMul(x, x))
+
+	return z.integrateQuotient(b, z.temp(1, order).setDerivative(x), u, z.temp(2, order))
+}
+
+/* powers */
+
+func (m *UpperTriToeplitzOf[T]) PowReal(p float64) *UpperTriToeplitzOf[T] {
+	return m.result().SetPowReal(m, p).detach()
+}
+
+/*
+J.C.P. Miller's recurrence for b = a^p with a real exponent. from
+a * b' = p * a' * b, matching coefficients gives:
+
+b_k = 1/(k*a_0) * Σ ((p+1)*j - k) * a_j * b_{k-j}
+
+this needs a_0 != 0. when a_0 == 0, the series only exists for
+non-negative integer exponents, so those are handled by Pow and
+everything else is NaN. x^p starts at x^p, so from p = order on the
+series is all zeros, which also keeps p within the range of an int.
+*/
+func (z *UpperTriToeplitzOf[T]) SetPowReal(x *UpperTriToeplitzOf[T], p float64) *UpperTriToeplitzOf[T] {
+	if x.order == 0 {
+		z.resize(0)
+
+		return z
+	}
+
+	if x.get(0) == 0 {
+		if p < 0 || p != math.Trunc(p) {
+			return z.setNaN(x.order)
+		}
+
+		if p >= float64(x.order) {
+			z.resize(x.order)
+			z.Reset(0)
+
+			return z
+		}
+
+		return z.SetPow(x, int(p))
+	}
+
+	return z.setPowMiller(z.input(x, 0), fromFloat[T](p))
+}
+
+func (m *UpperTriToeplitzOf[T]) powMiller(p T) *UpperTriToeplitzOf[T] {
+	return m.result().setPowMiller(m, p).detach()
+}
+
+// the sum in the recurrence is split in two, (p+1) * Σ j*a_j*b_{k-j}
+// and k * Σ a_j*b_{k-j}, so that j never needs converting to T. x is
+// read throughout, so it can't be z.
+func (z *UpperTriToeplitzOf[T]) setPowMiller(x *UpperTriToeplitzOf[T], p T) *UpperTriToeplitzOf[T] {
+	z.resize(x.order)
+	if x.order == 0 {
+		return z
+	}
+
+	da := z.temp(1, x.order).setDerivative(x)
+
+	a := x.get(0)
+	z.set(0, scalarPow(a, p))
+	for k := 1; k < x.order; k++ {
+		var sumJ, sum T
+		for j := 1; j <= k; j++ {
+			sumJ += da.get(j-1) * z.get(k-j)
+			sum += x.get(j) * z.get(k-j)
+		}
+		fk := fromInt[T](k)
+		z.set(k, ((p+1)*sumJ-fk*sum)/(fk*a))
+	}
+
+	return z
+}
+
+func (m *UpperTriToeplitzOf[T]) PowToeplitz(e *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	return m.result().SetPowToeplitz(m, e).detach()
+}
+
+// a^e = exp(e * log(a)), which needs a_0 > 0 unless e is a constant
+func (z *UpperTriToeplitzOf[T]) SetPowToeplitz(x, e *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	constant := true
+	for i := 1; i < e.order; i++ {
+		if e.get(i) != 0 {
+			constant = false
+			break
+		}
+	}
+
+	// complex exponents go straight to the recurrence, since only a
+	// real exponent can make sense of a zero value
+	if constant && isComplex[T]() && x.get(0) != 0 {
+		return z.setPowMiller(z.input(x, 0), e.get(0))
+	}
+
+	if constant {
+		return z.SetPowReal(x, realPart(e.get(0)))
+	}
+
+	// SetExp only uses the first scratch matrix
+	log := z.temp(1, x.order).SetLog(x)
+
+	return z.SetExp(log.SetMul(log, e))
+}
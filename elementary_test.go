@@ -0,0 +1,490 @@
+package gdual
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-12
+
+/* utils */
+
+func almostEqual(a, b float64) bool {
+	if a == b {
+		return true
+	}
+
+	diff := math.Abs(a - b)
+	scale := math.Max(1.0, math.Max(math.Abs(a), math.Abs(b)))
+
+	return diff <= tolerance*scale
+}
+
+func factorial(k int) float64 {
+	res := 1.0
+	for i := 2; i <= k; i++ {
+		res *= float64(i)
+	}
+
+	return res
+}
+
+// the seed of a variable, as a Toeplitz matrix
+func variableToeplitz(order int, seed float64) *UpperTriToeplitz {
+	mat := NewUpperTriToeplitz(order)
+	mat.Fill(0, seed)
+	mat.Fill(1, 1.0)
+
+	return mat
+}
+
+// builds the expected Taylor coefficients from the k-th derivative
+func taylorCoefficients(order int, derivative func(k int) float64) []float64 {
+	res := make([]float64, order)
+	for k := range res {
+		res[k] = derivative(k) / factorial(k)
+	}
+
+	return res
+}
+
+/* tests */
+
+func TestElementary(t *testing.T) {
+	order := 8
+	seed := 0.7
+
+	tests := []struct {
+		name     string
+		fn       func(*UpperTriToeplitz) *UpperTriToeplitz
+		expected []float64
+	}{
+		{
+			name: "exp",
+			fn:   (*UpperTriToeplitz).Exp,
+			expected: taylorCoefficients(order, func(k int) float64 {
+				return math.Exp(seed)
+			}),
+		},
+		{
+			name: "log",
+			fn:   (*UpperTriToeplitz).Log,
+			expected: taylorCoefficients(order, func(k int) float64 {
+				if k == 0 {
+					return math.Log(seed)
+				}
+				return math.Pow(-1, float64(k+1)) * factorial(k-1) / math.Pow(seed, float64(k))
+			}),
+		},
+		{
+			name: "sqrt",
+			fn:   (*UpperTriToeplitz).Sqrt,
+			expected: taylorCoefficients(order, func(k int) float64 {
+				res := math.Pow(seed, 0.5-float64(k))
+				for i := 0; i < k; i++ {
+					res *= 0.5 - float64(i)
+				}
+				return res
+			}),
+		},
+		{
+			name: "sin",
+			fn:   (*UpperTriToeplitz).Sin,
+			expected: taylorCoefficients(order, func(k int) float64 {
+				return math.Sin(seed + float64(k)*math.Pi/2)
+			}),
+		},
+		{
+			name: "cos",
+			fn:   (*UpperTriToeplitz).Cos,
+			expected: taylorCoefficients(order, func(k int) float64 {
+				return math.Cos(seed + float64(k)*math.Pi/2)
+			}),
+		},
+		{
+			name: "sinh",
+			fn:   (*UpperTriToeplitz).Sinh,
+			expected: taylorCoefficients(order, func(k int) float64 {
+				if k%2 == 0 {
+					return math.Sinh(seed)
+				}
+				return math.Cosh(seed)
+			}),
+		},
+		{
+			name: "cosh",
+			fn:   (*UpperTriToeplitz).Cosh,
+			expected: taylorCoefficients(order, func(k int) float64 {
+				if k%2 == 0 {
+					return math.Cosh(seed)
+				}
+				return math.Sinh(seed)
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		mat := tt.fn(variableToeplitz(order, seed))
+		if mat.order != order {
+			t.Errorf("order mismatch on %s: have %d want %d",
+				tt.name, mat.order, order)
+		}
+
+		for n := 0; n < order; n++ {
+			if !almostEqual(mat.get(n), tt.expected[n]) {
+				t.Errorf("value mismatch on %s (col %d): have %g want %g",
+					tt.name, n, mat.get(n), tt.expected[n])
+			}
+		}
+	}
+}
+
+func TestElementaryQuotients(t *testing.T) {
+	// tan and tanh are checked against the quotient of their parts
+	inp := []float64{0.3, -1.2, 0.5, 2.0, -0.25, 0.1}
+
+	tests := []struct {
+		name     string
+		have     *UpperTriToeplitz
+		expected *UpperTriToeplitz
+	}{
+		{
+			name:     "tan",
+			have:     importUpperTriToeplitz(inp).Tan(),
+			expected: importUpperTriToeplitz(inp).Sin().Div(importUpperTriToeplitz(inp).Cos()),
+		},
+		{
+			name:     "tanh",
+			have:     importUpperTriToeplitz(inp).Tanh(),
+			expected: importUpperTriToeplitz(inp).Sinh().Div(importUpperTriToeplitz(inp).Cosh()),
+		},
+	}
+
+	for _, tt := range tests {
+		for n := 0; n < len(inp); n++ {
+			if !almostEqual(tt.have.get(n), tt.expected.get(n)) {
+				t.Errorf("value mismatch on %s (col %d): have %g want %g",
+					tt.name, n, tt.have.get(n), tt.expected.get(n))
+			}
+		}
+	}
+}
+
+func TestElementaryInverses(t *testing.T) {
+	inp := []float64{1.5, 0.5, -2.0, 0.75, 1.0, -0.3}
+
+	tests := []struct {
+		name string
+		have *UpperTriToeplitz
+	}{
+		{
+			name: "log(exp(x))",
+			have: importUpperTriToeplitz(inp).Exp().Log(),
+		},
+		{
+			name: "exp(log(x))",
+			have: importUpperTriToeplitz(inp).Log().Exp(),
+		},
+		{
+			name: "sqrt(x)^2",
+			have: importUpperTriToeplitz(inp).Sqrt().Pow(2),
+		},
+	}
+
+	for _, tt := range tests {
+		for n := 0; n < len(inp); n++ {
+			if !almostEqual(tt.have.get(n), inp[n]) {
+				t.Errorf("value mismatch on %s (col %d): have %g want %g",
+					tt.name, n, tt.have.get(n), inp[n])
+			}
+		}
+	}
+}
+
+func TestInverseFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		inp     []float64
+		forward func(*UpperTriToeplitz) *UpperTriToeplitz
+		inverse func(*UpperTriToeplitz) *UpperTriToeplitz
+	}{
+		{
+			name:    "sin(asin(x))",
+			inp:     []float64{0.4, 1.0, -0.5, 0.25, 0.1, -0.2},
+			forward: (*UpperTriToeplitz).Sin,
+			inverse: (*UpperTriToeplitz).Asin,
+		},
+		{
+			name:    "cos(acos(x))",
+			inp:     []float64{-0.6, 1.0, 0.3, -0.25, 0.5, 0.2},
+			forward: (*UpperTriToeplitz).Cos,
+			inverse: (*UpperTriToeplitz).Acos,
+		},
+		{
+			name:    "tan(atan(x))",
+			inp:     []float64{2.5, 1.0, -1.5, 0.25, 0.5, -0.2},
+			forward: (*UpperTriToeplitz).Tan,
+			inverse: (*UpperTriToeplitz).Atan,
+		},
+		{
+			name:    "sinh(asinh(x))",
+			inp:     []float64{-1.5, 1.0, 0.5, 0.75, -0.5, 0.2},
+			forward: (*UpperTriToeplitz).Sinh,
+			inverse: (*UpperTriToeplitz).Asinh,
+		},
+		{
+			name:    "cosh(acosh(x))",
+			inp:     []float64{1.8, 1.0, 0.5, -0.25, 0.5, 0.2},
+			forward: (*UpperTriToeplitz).Cosh,
+			inverse: (*UpperTriToeplitz).Acosh,
+		},
+		{
+			name:    "tanh(atanh(x))",
+			inp:     []float64{0.3, 1.0, -0.5, 0.25, 0.5, -0.2},
+			forward: (*UpperTriToeplitz).Tanh,
+			inverse: (*UpperTriToeplitz).Atanh,
+		},
+	}
+
+	for _, tt := range tests {
+		mat := tt.forward(tt.inverse(importUpperTriToeplitz(tt.inp)))
+
+		for n := 0; n < len(tt.inp); n++ {
+			if !almostEqual(mat.get(n), tt.inp[n]) {
+				t.Errorf("value mismatch on %s (col %d): have %g want %g",
+					tt.name, n, mat.get(n), tt.inp[n])
+			}
+		}
+	}
+}
+
+func TestInverseDerivatives(t *testing.T) {
+	seed := 0.6
+
+	tests := []struct {
+		name     string
+		fn       func(*UpperTriToeplitz) *UpperTriToeplitz
+		seed     float64
+		expected float64
+	}{
+		{"asin", (*UpperTriToeplitz).Asin, seed, 1 / math.Sqrt(1-seed*seed)},
+		{"acos", (*UpperTriToeplitz).Acos, seed, -1 / math.Sqrt(1-seed*seed)},
+		{"atan", (*UpperTriToeplitz).Atan, seed, 1 / (1 + seed*seed)},
+		{"asinh", (*UpperTriToeplitz).Asinh, seed, 1 / math.Sqrt(seed*seed+1)},
+		{"acosh", (*UpperTriToeplitz).Acosh, seed + 1, 1 / math.Sqrt((seed+1)*(seed+1)-1)},
+		{"atanh", (*UpperTriToeplitz).Atanh, seed, 1 / (1 - seed*seed)},
+	}
+
+	for _, tt := range tests {
+		mat := tt.fn(variableToeplitz(4, tt.seed))
+
+		if !almostEqual(mat.get(1), tt.expected) {
+			t.Errorf("derivative mismatch on %s: have %g want %g",
+				tt.name, mat.get(1), tt.expected)
+		}
+	}
+}
+
+func TestInverseDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(*UpperTriToeplitz) *UpperTriToeplitz
+		seed float64
+	}{
+		{"asin", (*UpperTriToeplitz).Asin, 1.5},
+		{"acos", (*UpperTriToeplitz).Acos, -1.5},
+		{"acosh", (*UpperTriToeplitz).Acosh, 0.5},
+		{"atanh", (*UpperTriToeplitz).Atanh, 2.0},
+
+		// the derivatives are infinite at the branch points
+		{"asin", (*UpperTriToeplitz).Asin, 1.0},
+		{"asin", (*UpperTriToeplitz).Asin, -1.0},
+		{"acos", (*UpperTriToeplitz).Acos, 1.0},
+		{"acos", (*UpperTriToeplitz).Acos, -1.0},
+		{"acosh", (*UpperTriToeplitz).Acosh, 1.0},
+	}
+
+	for _, tt := range tests {
+		mat := tt.fn(variableToeplitz(4, tt.seed))
+
+		for n := 0; n < mat.order; n++ {
+			if !math.IsNaN(mat.get(n)) {
+				t.Errorf("expected NaN on %s(%g) (col %d): have %g",
+					tt.name, tt.seed, n, mat.get(n))
+			}
+		}
+	}
+
+	// but the value alone exists, as in the big backend
+	values := []struct {
+		name     string
+		fn       func(*UpperTriToeplitz) *UpperTriToeplitz
+		seed     float64
+		expected float64
+	}{
+		{"asin", (*UpperTriToeplitz).Asin, 1.0, math.Pi / 2},
+		{"asin", (*UpperTriToeplitz).Asin, -1.0, -math.Pi / 2},
+		{"acos", (*UpperTriToeplitz).Acos, 1.0, 0.0},
+		{"acos", (*UpperTriToeplitz).Acos, -1.0, math.Pi},
+		{"acosh", (*UpperTriToeplitz).Acosh, 1.0, 0.0},
+	}
+
+	for _, tt := range values {
+		if have := tt.fn(importUpperTriToeplitz([]float64{tt.seed})).get(0); have != tt.expected {
+			t.Errorf("value mismatch on %s(%g): have %g want %g", tt.name, tt.seed, have, tt.expected)
+		}
+	}
+}
+
+func TestAtan2(t *testing.T) {
+	order := 6
+
+	tests := []struct {
+		y float64
+		x float64
+	}{
+		{y: 0.5, x: 2.0},
+		{y: -1.5, x: 0.75},
+		{y: 2.0, x: -1.0},
+		{y: -0.5, x: -3.0},
+	}
+
+	for i, tt := range tests {
+		y := variableToeplitz(order, tt.y)
+		x := NewUpperTriToeplitz(order)
+		x.Fill(0, tt.x)
+		x.Fill(2, 0.5)
+
+		mat := y.Atan2(x)
+
+		// atan2 only differs from atan(y/x) by a constant
+		expected := y.Div(x).Atan()
+		expected.Fill(0, math.Atan2(tt.y, tt.x))
+
+		for n := 0; n < order; n++ {
+			if !almostEqual(mat.get(n), expected.get(n)) {
+				t.Errorf("value mismatch on atan2 test %d (col %d): have %g want %g",
+					i, n, mat.get(n), expected.get(n))
+			}
+		}
+	}
+}
+
+func TestPowReal(t *testing.T) {
+	order := 7
+	seed := 1.7
+
+	tests := []struct {
+		p float64
+	}{
+		{p: 0.5},
+		{p: -1},
+		{p: -2.5},
+		{p: 0},
+		{p: 3},
+		{p: 1.0 / 3.0},
+	}
+
+	for i, tt := range tests {
+		mat := variableToeplitz(order, seed).PowReal(tt.p)
+
+		expected := taylorCoefficients(order, func(k int) float64 {
+			res := math.Pow(seed, tt.p-float64(k))
+			for j := 0; j < k; j++ {
+				res *= tt.p - float64(j)
+			}
+			return res
+		})
+
+		for n := 0; n < order; n++ {
+			if !almostEqual(mat.get(n), expected[n]) {
+				t.Errorf("value mismatch on pow test %d (col %d): have %g want %g",
+					i, n, mat.get(n), expected[n])
+			}
+		}
+	}
+}
+
+func TestPowRealZeroSeed(t *testing.T) {
+	order := 5
+
+	tests := []struct {
+		p        float64
+		expected []float64
+	}{
+		{
+			p:        0,
+			expected: []float64{1, 0, 0, 0, 0},
+		},
+		{
+			p:        3,
+			expected: []float64{0, 0, 0, 1, 0},
+		},
+		{
+			p:        0.5,
+			expected: []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()},
+		},
+		{
+			p:        -1,
+			expected: []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()},
+		},
+		{
+			p:        5,
+			expected: []float64{0, 0, 0, 0, 0},
+		},
+		{
+			// far past the range of an int
+			p:        1e300,
+			expected: []float64{0, 0, 0, 0, 0},
+		},
+	}
+
+	for i, tt := range tests {
+		mat := variableToeplitz(order, 0).PowReal(tt.p)
+
+		for n := 0; n < order; n++ {
+			have := mat.get(n)
+			if math.IsNaN(tt.expected[n]) && math.IsNaN(have) {
+				continue
+			}
+
+			if have != tt.expected[n] {
+				t.Errorf("value mismatch on zero seed test %d (col %d): have %g want %g",
+					i, n, have, tt.expected[n])
+			}
+		}
+	}
+}
+
+func TestPowToeplitz(t *testing.T) {
+	order := 6
+	seed := 2.0
+
+	// f(x) = x^x, where f'(x) = x^x * (log(x) + 1)
+	x := variableToeplitz(order, seed)
+	mat := x.PowToeplitz(x)
+
+	if !almostEqual(mat.get(0), 4.0) {
+		t.Errorf("value mismatch on x^x: have %g want %g", mat.get(0), 4.0)
+	}
+
+	derivative := 4.0 * (math.Log(seed) + 1)
+	if !almostEqual(mat.get(1), derivative) {
+		t.Errorf("derivative mismatch on x^x: have %g want %g", mat.get(1), derivative)
+	}
+
+	// a constant exponent falls back to the real power, even for a negative base
+	e := NewUpperTriToeplitz(order)
+	e.Fill(0, 3.0)
+
+	base := variableToeplitz(order, -2.0)
+	mat = base.PowToeplitz(e)
+	expected := base.Pow(3)
+
+	for n := 0; n < order; n++ {
+		if !almostEqual(mat.get(n), expected.get(n)) {
+			t.Errorf("value mismatch on constant exponent (col %d): have %g want %g",
+				n, mat.get(n), expected.get(n))
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package gdual
+
+import (
+	"errors"
+)
+
+var (
+	ErrIndexOutOfRange   = errors.New("gdual: index out of range")
+	ErrOrderMismatch     = errors.New("gdual: order mismatch")
+	ErrDivisionByZero    = errors.New("gdual: division by zero")
+	ErrDomain            = errors.New("gdual: argument outside of the domain")
+	ErrNotRational       = errors.New("gdual: result is not rational")
+	ErrUnboundVariable   = errors.New("gdual: variable without a seed")
+	ErrDimensionMismatch = errors.New("gdual: dimension mismatch")
+	ErrNotInvertible     = errors.New("gdual: series is not invertible")
+	ErrOverflow          = errors.New("gdual: result overflows")
+	ErrContextMismatch   = errors.New("gdual: values from different contexts")
+	ErrRedeclared        = errors.New("gdual: variable declared twice")
+	ErrTooManyVariables  = errors.New("gdual: context already has a variable")
+)
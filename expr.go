@@ -0,0 +1,480 @@
+/*
+
+lazy expressions.
+
+every GDual operation allocates and fills a new matrix straight away,
+so an expression has to be rebuilt for every seed it's evaluated at.
+an Expr records the operations instead, as a node in a directed acyclic
+graph: variables and constants are the leaves, and every other node is
+an operation on the nodes below it. the graph can then be evaluated at
+any seed and any order, as many times as needed.
+
+nodes are shared rather than copied, so in
+
+	y := x.Sin()
+	z := y.Mul(y)
+
+the sine is a single node, and is evaluated once per evaluation of z.
+
+variables are named by a symbol, like MultiGDual. Eval seeds each of
+them as a GDual variable, so with more than one symbol it gives the
+derivatives along the direction where every variable moves at once
+(the total derivative), and EvalMulti gives every mixed partial.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+type op int
+
+const (
+	opVariable op = iota
+	opConstant
+
+	opAdd
+	opSub
+	opMul
+	opDiv
+	opInv
+	opPow
+	opPowReal
+	opPowExpr
+
+	opExp
+	opLog
+	opSqrt
+	opSin
+	opCos
+	opTan
+	opSinh
+	opCosh
+	opTanh
+
+	opAsin
+	opAcos
+	opAtan
+	opAtan2
+	opAsinh
+	opAcosh
+	opAtanh
+)
+
+var opNames = map[op]string{
+	opAdd:     "+",
+	opSub:     "-",
+	opMul:     "*",
+	opDiv:     "/",
+	opInv:     "inv",
+	opPow:     "^",
+	opPowReal: "^",
+	opPowExpr: "^",
+	opExp:     "exp",
+	opLog:     "log",
+	opSqrt:    "sqrt",
+	opSin:     "sin",
+	opCos:     "cos",
+	opTan:     "tan",
+	opSinh:    "sinh",
+	opCosh:    "cosh",
+	opTanh:    "tanh",
+	opAsin:    "asin",
+	opAcos:    "acos",
+	opAtan:    "atan",
+	opAtan2:   "atan2",
+	opAsinh:   "asinh",
+	opAcosh:   "acosh",
+	opAtanh:   "atanh",
+}
+
+// Expr is a node of an expression graph. nodes are immutable once created.
+type Expr struct {
+	op     op
+	args   []*Expr
+	symbol string  // the name of a variable
+	value  float64 // the value of a constant, or the exponent of PowReal
+	n      int     // the exponent of Pow
+}
+
+// NewVariable creates the variable named by symbol
+func NewVariable(symbol string) *Expr {
+	return &Expr{op: opVariable, symbol: symbol}
+}
+
+// NewConstant creates a constant, which depends on no variables
+func NewConstant(val float64) *Expr {
+	return &Expr{op: opConstant, value: val}
+}
+
+func newExpr(op op, args ...*Expr) *Expr {
+	return &Expr{op: op, args: args}
+}
+
+/* graph */
+
+// nodes returns every node in the graph once, with each node after its arguments
+func (e *Expr) nodes() []*Expr {
+	var nodes []*Expr
+	seen := make(map[*Expr]bool)
+
+	var visit func(n *Expr)
+	visit = func(n *Expr) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+
+		for _, arg := range n.args {
+			visit(arg)
+		}
+		nodes = append(nodes, n)
+	}
+	visit(e)
+
+	return nodes
+}
+
+// NodeCount returns the number of distinct nodes in the graph, leaves included
+func (e *Expr) NodeCount() int {
+	return len(e.nodes())
+}
+
+// Depth returns the number of operations on the longest path from a leaf
+func (e *Expr) Depth() int {
+	depth := make(map[*Expr]int)
+	for _, n := range e.nodes() {
+		for _, arg := range n.args {
+			if depth[arg]+1 > depth[n] {
+				depth[n] = depth[arg] + 1
+			}
+		}
+	}
+
+	return depth[e]
+}
+
+// Symbols returns the sorted symbols of every variable the expression depends on
+func (e *Expr) Symbols() []string {
+	var symbols []string
+	for _, n := range e.nodes() {
+		if n.op == opVariable {
+			symbols = append(symbols, n.symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	// the same symbol can be used by more than one node
+	out := symbols[:0]
+	for i, s := range symbols {
+		if i == 0 || s != symbols[i-1] {
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
+
+func (e *Expr) String() string {
+	switch e.op {
+	case opVariable:
+		return e.symbol
+	case opConstant:
+		return strconv.FormatFloat(e.value, 'g', -1, 64)
+	case opAdd, opSub, opMul, opDiv, opPowExpr:
+		return fmt.Sprintf("(%s %s %s)", e.args[0], opNames[e.op], e.args[1])
+	case opPow:
+		return fmt.Sprintf("(%s ^ %d)", e.args[0], e.n)
+	case opPowReal:
+		return fmt.Sprintf("(%s ^ %s)", e.args[0], strconv.FormatFloat(e.value, 'g', -1, 64))
+	case opAtan2:
+		return fmt.Sprintf("atan2(%s, %s)", e.args[0], e.args[1])
+	default:
+		return fmt.Sprintf("%s(%s)", opNames[e.op], e.args[0])
+	}
+}
+
+/* evaluation */
+
+// the operations shared by GDual and MultiGDual
+type algebra[V any] interface {
+	Add(inp V) V
+	Sub(inp V) V
+	Mul(inp V) V
+	Div(inp V) V
+	Inv() V
+	Pow(n int) V
+	PowReal(p float64) V
+	PowGDual(e V) V
+
+	Exp() V
+	Log() V
+	Sqrt() V
+	Sin() V
+	Cos() V
+	Tan() V
+	Sinh() V
+	Cosh() V
+	Tanh() V
+
+	Asin() V
+	Acos() V
+	Atan() V
+	Atan2(inp V) V
+	Asinh() V
+	Acosh() V
+	Atanh() V
+}
+
+// evaluates every node once, in order, with leaf creating the variables and constants
+func evaluate[V algebra[V]](e *Expr, leaf func(n *Expr) V) V {
+	vals := make(map[*Expr]V)
+	for _, n := range e.nodes() {
+		var a, b V
+		if len(n.args) > 0 {
+			a = vals[n.args[0]]
+		}
+		if len(n.args) > 1 {
+			b = vals[n.args[1]]
+		}
+
+		var val V
+		if n.op == opVariable || n.op == opConstant {
+			val = leaf(n)
+		} else {
+			val = applyOp(n.op, n.n, n.value, a, b)
+		}
+		vals[n] = val
+	}
+
+	return vals[e]
+}
+
+/*
+applyOp applies an operation to its arguments, for every evaluator of the
+operations (Expr graphs and the Tape): b is the second argument of binary
+operations, n the exponent of Pow and p the exponent of PowReal.
+variables and constants have no arguments, so the caller creates them.
+*/
+func applyOp[V algebra[V]](o op, n int, p float64, a, b V) V {
+	switch o {
+	case opAdd:
+		return a.Add(b)
+	case opSub:
+		return a.Sub(b)
+	case opMul:
+		return a.Mul(b)
+	case opDiv:
+		return a.Div(b)
+	case opInv:
+		return a.Inv()
+	case opPow:
+		return a.Pow(n)
+	case opPowReal:
+		return a.PowReal(p)
+	case opPowExpr:
+		return a.PowGDual(b)
+	case opExp:
+		return a.Exp()
+	case opLog:
+		return a.Log()
+	case opSqrt:
+		return a.Sqrt()
+	case opSin:
+		return a.Sin()
+	case opCos:
+		return a.Cos()
+	case opTan:
+		return a.Tan()
+	case opSinh:
+		return a.Sinh()
+	case opCosh:
+		return a.Cosh()
+	case opTanh:
+		return a.Tanh()
+	case opAsin:
+		return a.Asin()
+	case opAcos:
+		return a.Acos()
+	case opAtan:
+		return a.Atan()
+	case opAtan2:
+		return a.Atan2(b)
+	case opAsinh:
+		return a.Asinh()
+	case opAcosh:
+		return a.Acosh()
+	case opAtanh:
+		return a.Atanh()
+	}
+
+	panic(fmt.Sprintf("gdual: operation %d has no arguments to apply it to", o))
+}
+
+// checks that every symbol has a seed
+func checkSeeds[T any](e *Expr, seeds map[string]T) error {
+	for _, s := range e.Symbols() {
+		if _, ok := seeds[s]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnboundVariable, s)
+		}
+	}
+
+	return nil
+}
+
+// Eval evaluates the expression as a GDual, with every variable seeded from seeds
+func (e *Expr) Eval(order int, seeds map[string]float64) (*GDual, error) {
+	return EvalOf(e, order, seeds)
+}
+
+// EvalOf evaluates the expression as a GDual of any element type
+func EvalOf[T Field](e *Expr, order int, seeds map[string]T) (*GDualOf[T], error) {
+	if err := checkSeeds(e, seeds); err != nil {
+		return nil, err
+	}
+
+	gdual := evaluate(e, func(n *Expr) *GDualOf[T] {
+		if n.op == opVariable {
+			gdual := NewGDualOf(order, seeds[n.symbol], true)
+			gdual.symbols = []string{n.symbol}
+
+			return gdual
+		}
+
+		return NewGDualOf(order, fromFloat[T](n.value), false)
+	})
+
+	return gdual, nil
+}
+
+// EvalMulti evaluates the expression as a MultiGDual, for every mixed partial
+func (e *Expr) EvalMulti(order int, seeds map[string]float64) (*MultiGDual, error) {
+	if err := checkSeeds(e, seeds); err != nil {
+		return nil, err
+	}
+
+	gdual := evaluate(e, func(n *Expr) *MultiGDual {
+		if n.op == opVariable {
+			return NewMultiGDual(order, n.symbol, seeds[n.symbol])
+		}
+
+		return NewMultiConstant(order, n.value)
+	})
+
+	return gdual, nil
+}
+
+/* arithmetic */
+
+func (e *Expr) Add(inp *Expr) *Expr {
+	return newExpr(opAdd, e, inp)
+}
+
+func (e *Expr) Sub(inp *Expr) *Expr {
+	return newExpr(opSub, e, inp)
+}
+
+func (e *Expr) Mul(inp *Expr) *Expr {
+	return newExpr(opMul, e, inp)
+}
+
+func (e *Expr) Div(inp *Expr) *Expr {
+	return newExpr(opDiv, e, inp)
+}
+
+func (e *Expr) Inv() *Expr {
+	return newExpr(opInv, e)
+}
+
+func (e *Expr) Pow(n int) *Expr {
+	out := newExpr(opPow, e)
+	out.n = n
+
+	return out
+}
+
+/* elementary functions */
+
+func (e *Expr) Exp() *Expr {
+	return newExpr(opExp, e)
+}
+
+func (e *Expr) Log() *Expr {
+	return newExpr(opLog, e)
+}
+
+func (e *Expr) Sqrt() *Expr {
+	return newExpr(opSqrt, e)
+}
+
+func (e *Expr) Sin() *Expr {
+	return newExpr(opSin, e)
+}
+
+func (e *Expr) Cos() *Expr {
+	return newExpr(opCos, e)
+}
+
+func (e *Expr) Tan() *Expr {
+	return newExpr(opTan, e)
+}
+
+func (e *Expr) Sinh() *Expr {
+	return newExpr(opSinh, e)
+}
+
+func (e *Expr) Cosh() *Expr {
+	return newExpr(opCosh, e)
+}
+
+func (e *Expr) Tanh() *Expr {
+	return newExpr(opTanh, e)
+}
+
+/* inverse functions */
+
+func (e *Expr) Asin() *Expr {
+	return newExpr(opAsin, e)
+}
+
+func (e *Expr) Acos() *Expr {
+	return newExpr(opAcos, e)
+}
+
+func (e *Expr) Atan() *Expr {
+	return newExpr(opAtan, e)
+}
+
+// Atan2 returns atan(e / inp), using the signs of both to pick the quadrant
+func (e *Expr) Atan2(inp *Expr) *Expr {
+	return newExpr(opAtan2, e, inp)
+}
+
+func (e *Expr) Asinh() *Expr {
+	return newExpr(opAsinh, e)
+}
+
+func (e *Expr) Acosh() *Expr {
+	return newExpr(opAcosh, e)
+}
+
+func (e *Expr) Atanh() *Expr {
+	return newExpr(opAtanh, e)
+}
+
+/* powers */
+
+func (e *Expr) PowReal(p float64) *Expr {
+	out := newExpr(opPowReal, e)
+	out.value = p
+
+	return out
+}
+
+func (e *Expr) PowExpr(exponent *Expr) *Expr {
+	return newExpr(opPowExpr, e, exponent)
+}
@@ -0,0 +1,165 @@
+package gdual
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExprEval(t *testing.T) {
+	order := 8
+
+	tests := []struct {
+		name  string
+		expr  func(x *Expr) *Expr
+		eager func(x *GDual) *GDual
+	}{
+		{"rational", func(x *Expr) *Expr {
+			// 4x^2 / (1 - x)^3, as in TestComplex
+			return x.Pow(2).Mul(NewConstant(4)).Div(NewConstant(1).Sub(x).Pow(3))
+		}, func(x *GDual) *GDual {
+			one := NewGDual(order, 1.0, false)
+			four := NewGDual(order, 4.0, false)
+			return x.Pow(2).Mul(four).Div(one.Sub(x).Pow(3))
+		}},
+		{"elementary", func(x *Expr) *Expr {
+			return x.Sin().Exp().Add(x.Sqrt().Log()).Mul(x.Atan())
+		}, func(x *GDual) *GDual {
+			return x.Sin().Exp().Add(x.Sqrt().Log()).Mul(x.Atan())
+		}},
+		{"powers", func(x *Expr) *Expr {
+			return x.PowReal(-1.5).Add(x.PowExpr(x)).Sub(x.Inv())
+		}, func(x *GDual) *GDual {
+			return x.PowReal(-1.5).Add(x.PowGDual(x)).Sub(x.Inv())
+		}},
+		{"atan2", func(x *Expr) *Expr {
+			return x.Cosh().Atan2(x.Tanh())
+		}, func(x *GDual) *GDual {
+			return x.Cosh().Atan2(x.Tanh())
+		}},
+	}
+
+	for i, tt := range tests {
+		// the graph is built once, and evaluated at every seed
+		expr := tt.expr(NewVariable("x"))
+
+		for _, inp := range []float64{0.25, 0.5, 2.0, 3.0} {
+			have, err := expr.Eval(order, map[string]float64{"x": inp})
+			if err != nil {
+				t.Fatalf("failed on %s test %d: %v", tt.name, i, err)
+			}
+
+			expected := tt.eager(NewGDual(order, inp, true))
+			for k := 0; k < order; k++ {
+				if !almostEqual(have.mat.get(k), expected.mat.get(k)) {
+					t.Errorf("value mismatch on %s test %d (input %v, col %d): have %v want %v",
+						tt.name, i, inp, k, have.mat.get(k), expected.mat.get(k))
+				}
+			}
+		}
+	}
+}
+
+func TestExprGraph(t *testing.T) {
+	x := NewVariable("x")
+
+	// sin(x) is shared, so it's a single node
+	s := x.Sin()
+	y := s.Mul(s).Add(s)
+
+	if have := y.NodeCount(); have != 4 {
+		t.Errorf("value mismatch on node count: have %d want %d", have, 4)
+	}
+
+	if have := y.Depth(); have != 3 {
+		t.Errorf("value mismatch on depth: have %d want %d", have, 3)
+	}
+
+	if have := x.Depth(); have != 0 {
+		t.Errorf("value mismatch on leaf depth: have %d want %d", have, 0)
+	}
+
+	expected := "((sin(x) * sin(x)) + sin(x))"
+	if have := y.String(); have != expected {
+		t.Errorf("value mismatch on string: have %s want %s", have, expected)
+	}
+
+	z := NewVariable("z")
+	w := y.Mul(z).Add(NewVariable("x").Pow(2))
+	symbols := w.Symbols()
+	if len(symbols) != 2 || symbols[0] != "x" || symbols[1] != "z" {
+		t.Errorf("value mismatch on symbols: have %v want %v", symbols, []string{"x", "z"})
+	}
+}
+
+func TestExprMulti(t *testing.T) {
+	order := 4
+	seeds := map[string]float64{"x": 1.0, "y": 2.0}
+
+	// f(1.0, 2.0) = x*y^2 + sin(x)*exp(y), as in the README
+	x, y := NewVariable("x"), NewVariable("y")
+	expr := x.Mul(y.Pow(2)).Add(x.Sin().Mul(y.Exp()))
+
+	have, err := expr.EvalMulti(order, seeds)
+	if err != nil {
+		t.Fatalf("failed on multi test: %v", err)
+	}
+
+	mx := NewMultiGDual(order, "x", 1.0)
+	my := NewMultiGDual(order, "y", 2.0)
+	expected := mx.Mul(my.Pow(2)).Add(mx.Sin().Mul(my.Exp()))
+
+	for _, partials := range []map[string]int{
+		{}, {"x": 1}, {"y": 1}, {"x": 2, "y": 1}, {"y": 3},
+	} {
+		if !almostEqual(have.Derivative(partials), expected.Derivative(partials)) {
+			t.Errorf("value mismatch on multi test %v: have %v want %v",
+				partials, have.Derivative(partials), expected.Derivative(partials))
+		}
+	}
+
+	// the total derivative moves x and y together
+	total, err := expr.Eval(order, seeds)
+	if err != nil {
+		t.Fatalf("failed on total derivative: %v", err)
+	}
+
+	first := expected.Derivative(map[string]int{"x": 1}) + expected.Derivative(map[string]int{"y": 1})
+	if have, _ := total.Derivative(1); !almostEqual(have, first) {
+		t.Errorf("value mismatch on total derivative: have %v want %v", have, first)
+	}
+}
+
+func TestExprComplex(t *testing.T) {
+	order := 5
+	inp := complex(0.5, 1e-20)
+
+	// the same graph, evaluated with complex seeds
+	x := NewVariable("x")
+	expr := x.Exp().Mul(x.Sin())
+
+	have, err := EvalOf(expr, order, map[string]complex128{"x": inp})
+	if err != nil {
+		t.Fatalf("failed on complex test: %v", err)
+	}
+
+	z := NewComplexGDual(order, inp, true)
+	expected := z.Exp().Mul(z.Sin())
+	for k := 0; k < order; k++ {
+		if have.mat.get(k) != expected.mat.get(k) {
+			t.Errorf("value mismatch on complex test (col %d): have %v want %v",
+				k, have.mat.get(k), expected.mat.get(k))
+		}
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	expr := NewVariable("x").Mul(NewVariable("y"))
+
+	if _, err := expr.Eval(4, map[string]float64{"x": 1.0}); !errors.Is(err, ErrUnboundVariable) {
+		t.Errorf("error mismatch on eval: have %v want %v", err, ErrUnboundVariable)
+	}
+
+	if _, err := expr.EvalMulti(4, map[string]float64{"y": 1.0}); !errors.Is(err, ErrUnboundVariable) {
+		t.Errorf("error mismatch on multi eval: have %v want %v", err, ErrUnboundVariable)
+	}
+}
@@ -0,0 +1,267 @@
+/*
+
+FFT-based multiplication for high order Toeplitz matrices.
+
+multiplying two upper triangular Toeplitz matrices is a truncated
+convolution of their first rows, which is O(n^2) when done directly.
+a convolution is a pointwise product in the frequency domain, so with
+a radix-2 FFT the whole product costs O(nlogn), plus the overhead of
+working in complex arithmetic.
+
+the catch is accuracy. the error of an FFT convolution is relative to
+the size of the largest coefficient, while Taylor coefficients usually
+shrink geometrically (with the radius of convergence), so the small,
+high order coefficients would be lost in the rounding error of the big
+ones. to avoid this, both inputs are rescaled by x -> x*r before the
+transform, with r chosen so the rescaled coefficients have a roughly
+constant size, and the product is scaled back afterwards. since
+(a*b)(x*r) = a(x*r) * b(x*r), the rescaling doesn't change the product.
+
+a single r only flattens coefficients that decay geometrically. the
+coefficients of exp, sin and the like decay factorially, and no r keeps
+those within the precision of a float64. so along with the product, the
+transform also convolves the absolute values of the inputs, which is
+the size every coefficient of the product is made up of, and the same
+error the direct product has. when the error bound of the FFT isn't
+within fftTolerance of that, for any coefficient, the product is left
+to the direct convolution instead.
+
+*/
+
+package gdual
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// the order at which Mul switches from the direct to the FFT product.
+// on amd64, the two cross over somewhere between orders 256 and 320.
+const fftThreshold = 288
+
+// the largest error of a product through the FFT, relative to the size of
+// each coefficient, before it falls back to the direct product
+const fftTolerance = 1e-10
+
+// iterative, in-place radix-2 FFT. len(a) must be a power of two.
+func fft(a []complex128, invert bool) {
+	n := len(a)
+	if n <= 1 {
+		return
+	}
+
+	// bit reversal permutation
+	shift := bits.UintSize - bits.TrailingZeros(uint(n))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse(uint(i)) >> shift)
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	// every twiddle factor is computed directly once, rather than with a
+	// running product, which would accumulate error across each stage
+	sign := -1.0
+	if invert {
+		sign = 1.0
+	}
+
+	twiddle := make([]complex128, n/2)
+	for k := range twiddle {
+		s, c := math.Sincos(sign * 2 * math.Pi * float64(k) / float64(n))
+		twiddle[k] = complex(c, s)
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		stride := n / size
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				u := a[start+k]
+				v := a[start+k+half] * twiddle[k*stride]
+				a[start+k] = u + v
+				a[start+k+half] = u - v
+			}
+		}
+	}
+
+	if invert {
+		scale := complex(1/float64(n), 0)
+		for i := range a {
+			a[i] *= scale
+		}
+	}
+}
+
+// estimates log(r), such that |a_k| * r^k is roughly constant, with
+// a least squares fit of log|a_k| against k over the nonzero coefficients
+func logDecayRate(a []float64) (float64, int) {
+	var n, sumK, sumY, sumKK, sumKY float64
+	for k, val := range a {
+		if val == 0 {
+			continue
+		}
+
+		y := math.Log(math.Abs(val))
+		fk := float64(k)
+		n++
+		sumK += fk
+		sumY += y
+		sumKK += fk * fk
+		sumKY += fk * y
+	}
+
+	denom := n*sumKK - sumK*sumK
+	if n < 2 || denom == 0 {
+		return 0.0, int(n)
+	}
+
+	slope := (n*sumKY - sumK*sumY) / denom
+
+	return -slope, int(n)
+}
+
+// computes a_k * r^k, falling back to log space when r^k alone overflows
+func rescale(val float64, k int, logR float64) float64 {
+	if val == 0 {
+		return 0
+	}
+
+	scale := math.Exp(float64(k) * logR)
+	if scale != 0 && !math.IsInf(scale, 0) {
+		return val * scale
+	}
+
+	scaled := math.Exp(math.Log(math.Abs(val)) + float64(k)*logR)
+
+	return math.Copysign(scaled, val)
+}
+
+func finite(a []float64) bool {
+	for _, val := range a {
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// converts real coefficients to float64 for the transform
+func realCoefficients[T Field](val []T) []float64 {
+	if out, ok := any(val).([]float64); ok {
+		return out
+	}
+
+	out := make([]float64, len(val))
+	for i, v := range val {
+		out[i] = realPart(v)
+	}
+
+	return out
+}
+
+// the error bound of a convolution through the FFT, following Higham,
+// "Accuracy and Stability of Numerical Algorithms", section 24.1. the
+// constant is generous, so the bound holds in the worst case.
+func fftErrorBound(size int, normA, normB float64) float64 {
+	const epsilon = 0x1p-52
+	logN := float64(bits.TrailingZeros(uint(size)))
+
+	return 8 * epsilon * (logN + 1) * math.Sqrt(normA*normB)
+}
+
+// the product of the two real transforms packed into z, with the conjugate
+// symmetry of real transforms: A_k = (Z_k + conj(Z_{n-k})) / 2 and
+// B_k = (Z_k - conj(Z_{n-k})) / 2i, so A_k * B_k = (Z_k^2 - conj(Z_{n-k})^2) / 4i
+func packedProduct(z []complex128, k int) complex128 {
+	zk := z[k]
+	zc := cmplx.Conj(z[(len(z)-k)%len(z)])
+
+	return (zk*zk - zc*zc) * complex(0, -0.25)
+}
+
+// mulFFT returns false when the product through the FFT can't be trusted,
+// and then the direct product has to be used instead
+func (m *UpperTriToeplitzOf[T]) mulFFT(inp *UpperTriToeplitzOf[T]) (*UpperTriToeplitzOf[T], bool) {
+	// packing both inputs into one transform only works for real inputs
+	if isComplex[T]() {
+		return nil, false
+	}
+
+	order := minOrder(m.order, inp.order)
+	a := realCoefficients(m.val[:order])
+	b := realCoefficients(inp.val[:order])
+
+	// NaN and Inf would leak into every coefficient through the transform
+	if !finite(a) || !finite(b) {
+		return nil, false
+	}
+
+	// balance the rescaling between both inputs
+	logA, nA := logDecayRate(a)
+	logB, nB := logDecayRate(b)
+	logR := 0.0
+	if nA+nB > 0 {
+		logR = (logA*float64(nA) + logB*float64(nB)) / float64(nA+nB)
+	}
+
+	// the product has 2*order-1 terms, so this size avoids any wraparound
+	size := 1
+	for size < 2*order-1 {
+		size <<= 1
+	}
+
+	// both real inputs are packed into a single complex transform, z = a + ib,
+	// and so are their absolute values, in w
+	z := make([]complex128, size)
+	w := make([]complex128, size)
+	var normA, normB float64
+	for k := 0; k < order; k++ {
+		scaledA, scaledB := rescale(a[k], k, logR), rescale(b[k], k, logR)
+
+		// a coefficient lost to the rescaling can't be accounted for
+		if (scaledA == 0) != (a[k] == 0) || (scaledB == 0) != (b[k] == 0) ||
+			math.IsInf(scaledA, 0) || math.IsInf(scaledB, 0) {
+			return nil, false
+		}
+
+		z[k] = complex(scaledA, scaledB)
+		w[k] = complex(math.Abs(scaledA), math.Abs(scaledB))
+		normA += scaledA * scaledA
+		normB += scaledB * scaledB
+	}
+	fft(z, false)
+	fft(w, false)
+
+	// both products are real, so one inverse transform gives the product
+	// in its real part, and the product of the absolute values in its imaginary part
+	prod := make([]complex128, size)
+	for k := 0; k < size; k++ {
+		prod[k] = packedProduct(z, k) + packedProduct(w, k)*complex(0, 1)
+	}
+	fft(prod, true)
+
+	// the product of the absolute values has the same error, so it's only
+	// trusted once it's that much bigger than the bound. the coefficients
+	// it isn't are convolved directly, as long as there are few enough of
+	// them to keep the product O(nlogn).
+	bound := fftErrorBound(size, normA, normB)
+	budget := size * bits.TrailingZeros(uint(size))
+
+	out := NewUpperTriToeplitzOf[T](order)
+	for k := 0; k < order; k++ {
+		if imag(prod[k])-bound >= bound/fftTolerance {
+			out.set(k, fromFloat[T](rescale(real(prod[k]), k, -logR)))
+			continue
+		}
+
+		if budget -= k + 1; budget < 0 {
+			return nil, false
+		}
+		out.set(k, convolve(m, inp, k))
+	}
+
+	return out, true
+}
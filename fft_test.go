@@ -0,0 +1,172 @@
+package gdual
+
+import (
+	"math"
+	"testing"
+)
+
+// the FFT error is relative to the largest coefficient, not to each one
+func maxAbsDiff(a, b *UpperTriToeplitz) (float64, float64) {
+	diff, scale := 0.0, 0.0
+	for n := 0; n < a.order; n++ {
+		diff = math.Max(diff, math.Abs(a.get(n)-b.get(n)))
+		scale = math.Max(scale, math.Abs(b.get(n)))
+	}
+
+	return diff, scale
+}
+
+func TestFFT(t *testing.T) {
+	for _, size := range []int{1, 2, 8, 64} {
+		input := randFloats(-1, 1, size)
+
+		a := make([]complex128, size)
+		for i := range a {
+			a[i] = complex(input[i], 0)
+		}
+
+		fft(a, false)
+		fft(a, true)
+
+		for i := range a {
+			if !almostEqual(real(a[i]), input[i]) || math.Abs(imag(a[i])) > tolerance {
+				t.Errorf("value mismatch on size %d (index %d): have %v want %g",
+					size, i, a[i], input[i])
+			}
+		}
+	}
+}
+
+func TestMulFFT(t *testing.T) {
+	for _, order := range []int{1, 2, 17, 200, fftThreshold, 1000} {
+		inp1 := importUpperTriToeplitz(randFloats(-1, 1, order))
+		inp2 := importUpperTriToeplitz(randFloats(-1, 1, order))
+
+		have, ok := inp1.mulFFT(inp2)
+		if !ok {
+			t.Errorf("expected an FFT product on order %d", order)
+			continue
+		}
+
+		want := inp1.mulDirect(inp2)
+		if have.order != want.order {
+			t.Errorf("order mismatch on order %d: have %d want %d",
+				order, have.order, want.order)
+		}
+
+		diff, scale := maxAbsDiff(have, want)
+		if diff > 1e-10*math.Max(scale, 1.0) {
+			t.Errorf("value mismatch on order %d: max difference %g", order, diff)
+		}
+	}
+}
+
+func TestMulFFTReference(t *testing.T) {
+	order := 200
+	input1 := randFloats(-1, 1, order)
+	input2 := randFloats(-1, 1, order)
+
+	std := importMatrix(input1).Mul(importMatrix(input2))
+	want := NewUpperTriToeplitz(order)
+	for n := 0; n < order; n++ {
+		want.set(n, std.get(0, n))
+	}
+
+	have, ok := importUpperTriToeplitz(input1).mulFFT(importUpperTriToeplitz(input2))
+	if !ok {
+		t.Fatalf("expected an FFT product")
+	}
+
+	diff, scale := maxAbsDiff(have, want)
+	if diff > 1e-10*math.Max(scale, 1.0) {
+		t.Errorf("value mismatch against standard matrix: max difference %g", diff)
+	}
+}
+
+func TestMulFFTDecay(t *testing.T) {
+	order := 1000
+
+	// 1 / (1 - x/2) has coefficients 2^-k, and its square (k+1) * 2^-k,
+	// which spans far more than the precision of a float64
+	mat := NewUpperTriToeplitz(order)
+	for k := 0; k < order; k++ {
+		mat.set(k, math.Ldexp(1, -k))
+	}
+
+	have := mat.Mul(mat)
+	for k := 0; k < order; k++ {
+		want := float64(k+1) * math.Ldexp(1, -k)
+		if math.Abs(have.get(k)-want) > 1e-9*want {
+			t.Errorf("value mismatch on decaying series (col %d): have %g want %g",
+				k, have.get(k), want)
+		}
+	}
+}
+
+func TestMulFFTNonFinite(t *testing.T) {
+	order := fftThreshold
+
+	inp1 := importUpperTriToeplitz(randFloats(-1, 1, order))
+	inp2 := importUpperTriToeplitz(randFloats(-1, 1, order))
+	inp2.set(order-1, math.NaN())
+
+	// a NaN in the last coefficient must not leak into the others
+	mat := inp1.Mul(inp2)
+	for k := 0; k < order-1; k++ {
+		if math.IsNaN(mat.get(k)) {
+			t.Errorf("unexpected NaN (col %d)", k)
+		}
+	}
+
+	if !math.IsNaN(mat.get(order - 1)) {
+		t.Errorf("expected NaN (col %d): have %g", order-1, mat.get(order-1))
+	}
+}
+
+func TestMulFFTFactorial(t *testing.T) {
+	for _, order := range []int{fftThreshold, 1000} {
+		x := NewGDual(order, 1.0, true)
+		series := map[string]*GDual{"x": x, "exp": x.Exp(), "sin": x.Sin(), "cos": x.Cos()}
+
+		// factorially decaying coefficients can't be rescaled into the
+		// precision of the FFT, so every product has to match the direct one
+		for name1, inp1 := range series {
+			for name2, inp2 := range series {
+				a, b := inp1.mat.toeplitz(), inp2.mat.toeplitz()
+				have := a.Mul(b)
+				want := a.mulDirect(b)
+
+				for k := 0; k < order; k++ {
+					scale := 0.0
+					for i := 0; i <= k; i++ {
+						scale += math.Abs(a.get(i) * b.get(k-i))
+					}
+
+					if math.Abs(have.get(k)-want.get(k)) > 1e-10*scale {
+						t.Errorf("value mismatch on %s * %s, order %d (col %d): have %g want %g",
+							name1, name2, order, k, have.get(k), want.get(k))
+						break
+					}
+				}
+			}
+		}
+
+		if have := x.Exp().Mul(x).Value(); !almostEqual(have, math.E) {
+			t.Errorf("value mismatch on exp(x) * x, order %d: have %g want %g", order, have, math.E)
+		}
+
+		sin, cos := x.Sin(), x.Cos()
+		have := sin.Mul(sin).Add(cos.Mul(cos)).Coefficients()
+		for k, val := range have {
+			want := 0.0
+			if k == 0 {
+				want = 1.0
+			}
+
+			if math.Abs(val-want) > tolerance {
+				t.Errorf("value mismatch on sin^2 + cos^2, order %d (col %d): have %g want %g",
+					order, k, val, want)
+			}
+		}
+	}
+}
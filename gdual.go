@@ -1,65 +1,459 @@
 package gdual
 
-type GDual struct {
-	mat      *UpperTriToeplitz
+import (
+	"fmt"
+)
+
+// GDualOf is a generalized dual number over the element type T
+type GDualOf[T Field] struct {
+	mat      series[T]
 	variable bool
+	symbols  []string      // the context variables it depends on, see Context
+	ctx      *ContextOf[T] // the context it was created in, if any
 }
 
+// GDual is a generalized dual number of float64, the default element type
+type GDual = GDualOf[float64]
+
 func NewGDual(order int, seed float64, variable bool) *GDual {
-	mat := NewUpperTriToeplitz(order)
+	return NewGDualOf(order, seed, variable)
+}
+
+// NewGDualOf creates a GDual of any element type in Field
+func NewGDualOf[T Field](order int, seed T, variable bool) *GDualOf[T] {
+	return NewGDualFrom(NewUpperTriToeplitzOf[T], order, seed, variable)
+}
+
+// NewGDualFrom creates a GDual backed by the Series returned by constructor
+func NewGDualFrom[T Field, S Series[T, S]](constructor func(order int) S, order int, seed T, variable bool) *GDualOf[T] {
+	mat := constructor(order)
 	mat.Fill(0, seed)
 	if variable {
 		mat.Fill(1, 1.0)
 	}
 
-	gdual := &GDual{
-		mat:      mat,
+	b := backend[T, S]{
+		mat:         mat,
+		constructor: constructor,
+	}
+
+	gdual := &GDualOf[T]{
+		mat:      b,
 		variable: variable,
 	}
 
 	return gdual
 }
 
-func importGDual(mat *UpperTriToeplitz, variable bool) *GDual {
-	gdual := &GDual{
-		mat:      mat,
-		variable: variable,
+// importGDual wraps the result of an operation on parents, which it depends on
+func importGDual[T Field](mat series[T], parents ...*GDualOf[T]) *GDualOf[T] {
+	gdual := &GDualOf[T]{
+		mat: mat,
 	}
 
+	for _, p := range parents {
+		gdual.variable = gdual.variable || p.variable
+		if gdual.ctx == nil {
+			gdual.ctx = p.ctx
+		}
+		if len(p.symbols) > 0 {
+			gdual.symbols = unionSymbols(gdual.symbols, p.symbols)
+		}
+	}
+
+	return gdual
+}
+
+/* accessors */
+
+func (g *GDualOf[T]) Order() int {
+	return g.mat.order()
+}
+
+// IsVariable reports whether g depends on any variable
+func (g *GDualOf[T]) IsVariable() bool {
+	return g.variable
+}
+
+// Variables returns the sorted symbols of the Context variables g depends on
+func (g *GDualOf[T]) Variables() []string {
+	symbols := make([]string, len(g.symbols))
+	copy(symbols, g.symbols)
+
+	return symbols
+}
+
+// Value returns f(x0), the value of the function at the seed
+func (g *GDualOf[T]) Value() T {
+	return g.mat.get(0)
+}
+
+// Coefficient returns the k-th Taylor coefficient, f^(k)(x0) / k!
+func (g *GDualOf[T]) Coefficient(k int) (T, error) {
+	if k < 0 || k >= g.mat.order() {
+		return 0, fmt.Errorf("%w: coefficient %d of order %d", ErrIndexOutOfRange, k, g.mat.order())
+	}
+
+	return g.mat.get(k), nil
+}
+
+// Coefficients returns a copy of every Taylor coefficient
+func (g *GDualOf[T]) Coefficients() []T {
+	coefs := make([]T, g.mat.order())
+	for k := range coefs {
+		coefs[k] = g.mat.get(k)
+	}
+
+	return coefs
+}
+
+// Derivative returns the k-th derivative, f^(k)(x0)
+func (g *GDualOf[T]) Derivative(k int) (T, error) {
+	coef, err := g.Coefficient(k)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 2; i <= k; i++ {
+		coef *= fromInt[T](i)
+	}
+
+	return coef, nil
+}
+
+// Derivatives returns every derivative, from f(x0) up to f^(order-1)(x0)
+func (g *GDualOf[T]) Derivatives() []T {
+	derivs := g.Coefficients()
+
+	var factorial T = 1
+	for k := range derivs {
+		if k > 1 {
+			factorial *= fromInt[T](k)
+		}
+		derivs[k] *= factorial
+	}
+
+	return derivs
+}
+
+/* arithmetic */
+
+func (g *GDualOf[T]) Add(inp *GDualOf[T]) *GDualOf[T] {
+	g.mix(inp)
+	mat := g.mat.add(inp.mat)
+	gdual := importGDual(mat, g, inp)
+
 	return gdual
 }
 
-func (g *GDual) Add(inp *GDual) *GDual {
-	mat := g.mat.Add(inp.mat)
-	gdual := importGDual(mat, g.variable || inp.variable)
+func (g *GDualOf[T]) Sub(inp *GDualOf[T]) *GDualOf[T] {
+	g.mix(inp)
+	mat := g.mat.sub(inp.mat)
+	gdual := importGDual(mat, g, inp)
 
 	return gdual
 }
 
-func (g *GDual) Sub(inp *GDual) *GDual {
-	mat := g.mat.Sub(inp.mat)
-	gdual := importGDual(mat, g.variable || inp.variable)
+func (g *GDualOf[T]) Mul(inp *GDualOf[T]) *GDualOf[T] {
+	g.mix(inp)
+	mat := g.mat.mul(inp.mat)
+	gdual := importGDual(mat, g, inp)
 
 	return gdual
 }
 
-func (g *GDual) Mul(inp *GDual) *GDual {
-	mat := g.mat.Mul(inp.mat)
-	gdual := importGDual(mat, g.variable || inp.variable)
+func (g *GDualOf[T]) Div(inp *GDualOf[T]) *GDualOf[T] {
+	g.mix(inp)
+	mat := g.mat.div(inp.mat)
+	gdual := importGDual(mat, g, inp)
 
 	return gdual
 }
 
-func (g *GDual) Div(inp *GDual) *GDual {
-	mat := g.mat.Div(inp.mat)
-	gdual := importGDual(mat, g.variable || inp.variable)
+/*
+arithmetic with scalars. a scalar c is the constant series c + 0x + 0x^2 + ...,
+so adding it only changes the value, while multiplying scales every
+coefficient. either way it takes O(n), without creating the constant as
+a GDual first. dividing a scalar by g still needs the O(n^2) inverse of g.
+*/
+
+// AddScalar returns g + c
+func (g *GDualOf[T]) AddScalar(c T) *GDualOf[T] {
+	mat := g.mat.addScalar(c)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+// SubScalar returns g - c
+func (g *GDualOf[T]) SubScalar(c T) *GDualOf[T] {
+	mat := g.mat.addScalar(-c)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+// MulScalar returns g * c
+func (g *GDualOf[T]) MulScalar(c T) *GDualOf[T] {
+	mat := g.mat.mulScalar(c)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+// DivScalar returns g / c
+func (g *GDualOf[T]) DivScalar(c T) *GDualOf[T] {
+	mat := g.mat.divScalar(c)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+// ScalarSub returns c - g
+func (g *GDualOf[T]) ScalarSub(c T) *GDualOf[T] {
+	mat := g.mat.mulScalar(-1).addScalar(c)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+// ScalarDiv returns c / g
+func (g *GDualOf[T]) ScalarDiv(c T) *GDualOf[T] {
+	mat := g.mat.inv().mulScalar(c)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+/*
+the checked variants reject dual numbers of a different order, which
+would be truncated, and dual numbers from different contexts, whose
+variables are unrelated. the unchecked operations panic on the same,
+unless neither has a context (see mix). values from NewGDual belong to
+no context, and mix with any of them of the same order.
+*/
+func (g *GDualOf[T]) check(inp *GDualOf[T]) error {
+	if err := checkOrder(g.mat.order(), inp.mat.order()); err != nil {
+		return err
+	}
+
+	if g.ctx != nil && inp.ctx != nil && g.ctx != inp.ctx {
+		return ErrContextMismatch
+	}
+
+	return nil
+}
+
+func (g *GDualOf[T]) AddE(inp *GDualOf[T]) (*GDualOf[T], error) {
+	if err := g.check(inp); err != nil {
+		return nil, err
+	}
+
+	return g.Add(inp), nil
+}
+
+func (g *GDualOf[T]) SubE(inp *GDualOf[T]) (*GDualOf[T], error) {
+	if err := g.check(inp); err != nil {
+		return nil, err
+	}
+
+	return g.Sub(inp), nil
+}
+
+func (g *GDualOf[T]) MulE(inp *GDualOf[T]) (*GDualOf[T], error) {
+	if err := g.check(inp); err != nil {
+		return nil, err
+	}
+
+	return g.Mul(inp), nil
+}
+
+func (g *GDualOf[T]) Inv() *GDualOf[T] {
+	mat := g.mat.inv()
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) InvE() (*GDualOf[T], error) {
+	if g.mat.toeplitz().singular() {
+		return nil, ErrDivisionByZero
+	}
+
+	return g.Inv(), nil
+}
+
+func (g *GDualOf[T]) DivE(inp *GDualOf[T]) (*GDualOf[T], error) {
+	if err := g.check(inp); err != nil {
+		return nil, err
+	}
+
+	if inp.mat.toeplitz().singular() {
+		return nil, ErrDivisionByZero
+	}
+
+	return g.Div(inp), nil
+}
+
+// DivLimit divides, resolving 0/0 by L'Hôpital's rule at the cost of order
+func (g *GDualOf[T]) DivLimit(inp *GDualOf[T]) (*GDualOf[T], error) {
+	if err := g.check(inp); err != nil {
+		return nil, err
+	}
+
+	mat, err := g.mat.toeplitz().DivLimit(inp.mat.toeplitz())
+	if err != nil {
+		return nil, err
+	}
+	gdual := importGDual(g.mat.from(mat), g, inp)
+
+	return gdual, nil
+}
+
+func (g *GDualOf[T]) Pow(n int) *GDualOf[T] {
+	mat := g.mat.pow(n)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+/* elementary functions */
+
+func (g *GDualOf[T]) Exp() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Exp)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Log() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Log)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Sqrt() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Sqrt)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Sin() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Sin)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Cos() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Cos)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Tan() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Tan)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Sinh() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Sinh)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Cosh() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Cosh)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Tanh() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Tanh)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+/* inverse functions */
+
+func (g *GDualOf[T]) Asin() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Asin)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Acos() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Acos)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Atan() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Atan)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+// Atan2 returns atan(g / inp), using the signs of both to pick the quadrant
+func (g *GDualOf[T]) Atan2(inp *GDualOf[T]) *GDualOf[T] {
+	g.mix(inp)
+	mat := apply(g.mat, func(y *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+		return y.Atan2(inp.mat.toeplitz())
+	})
+	gdual := importGDual(mat, g, inp)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Asinh() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Asinh)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Acosh() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Acosh)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+func (g *GDualOf[T]) Atanh() *GDualOf[T] {
+	mat := apply(g.mat, (*UpperTriToeplitzOf[T]).Atanh)
+	gdual := importGDual(mat, g)
+
+	return gdual
+}
+
+/* powers */
+
+func (g *GDualOf[T]) PowReal(p float64) *GDualOf[T] {
+	mat := apply(g.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+		return m.PowReal(p)
+	})
+	gdual := importGDual(mat, g)
 
 	return gdual
 }
 
-func (g *GDual) Pow(n int) *GDual {
-	mat := g.mat.Pow(n)
-	gdual := importGDual(mat, g.variable)
+func (g *GDualOf[T]) PowGDual(e *GDualOf[T]) *GDualOf[T] {
+	g.mix(e)
+	mat := apply(g.mat, func(m *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+		return m.PowToeplitz(e.mat.toeplitz())
+	})
+	gdual := importGDual(mat, g, e)
 
 	return gdual
 }
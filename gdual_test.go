@@ -1,6 +1,7 @@
 package gdual
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -24,6 +25,17 @@ func TestSimple(t *testing.T) {
 	}
 }
 
+func TestDefaultType(t *testing.T) {
+	// untyped constants seed the float64 types, as they did before GDualOf
+	var x *GDual = NewGDual(5, 4, false)
+	var m *UpperTriToeplitz = NewUpperTriToeplitz(5)
+	m.Fill(0, 4)
+
+	if x.Value() != 4.0 || m.get(0) != 4.0 {
+		t.Errorf("value mismatch on default type: have %v and %v want %v", x.Value(), m.get(0), 4.0)
+	}
+}
+
 func TestComplex(t *testing.T) {
 	expected := []float64{
 		-4.5, 3.75, -2.75, 1.875,
@@ -48,3 +60,223 @@ func TestComplex(t *testing.T) {
 		}
 	}
 }
+
+func TestPythagorean(t *testing.T) {
+	order := 8
+	inp := 1.3
+
+	x := NewGDual(order, inp, true)
+
+	// f(1.3) = sin(x)^2 + cos(x)^2 = 1
+	y := x.Sin().Pow(2).Add(x.Cos().Pow(2))
+
+	for i := 0; i < order; i++ {
+		expected := 0.0
+		if i == 0 {
+			expected = 1.0
+		}
+
+		if !almostEqual(y.mat.get(i), expected) {
+			t.Errorf("failed on pythagorean test (iter %d): have %g want %g",
+				i, y.mat.get(i), expected)
+		}
+	}
+}
+
+func TestRealPower(t *testing.T) {
+	order := 6
+	inp := 4.0
+
+	x := NewGDual(order, inp, true)
+
+	// f(4.0) = x^0.5 = sqrt(x)
+	y := x.PowReal(0.5)
+	expected := x.Sqrt()
+
+	for i := 0; i < order; i++ {
+		if !almostEqual(y.mat.get(i), expected.mat.get(i)) {
+			t.Errorf("failed on real power test (iter %d): have %g want %g",
+				i, y.mat.get(i), expected.mat.get(i))
+		}
+	}
+}
+
+func TestDerivatives(t *testing.T) {
+	order := 5
+	inp := 2.0
+
+	x := NewGDual(order, inp, true)
+
+	// f(2.0) = x^4, f'(2.0) = 4x^3, f''(2.0) = 12x^2, ...
+	y := x.Pow(4)
+
+	if y.Order() != order {
+		t.Errorf("order mismatch: have %d want %d", y.Order(), order)
+	}
+
+	if y.Value() != 16.0 {
+		t.Errorf("value mismatch: have %.2f want %.2f", y.Value(), 16.0)
+	}
+
+	expected := []float64{16.0, 32.0, 48.0, 48.0, 24.0}
+	derivs := y.Derivatives()
+	for k := 0; k < order; k++ {
+		deriv, err := y.Derivative(k)
+		if err != nil {
+			t.Errorf("unexpected error on derivative %d: %v", k, err)
+		}
+
+		if deriv != expected[k] || derivs[k] != expected[k] {
+			t.Errorf("failed on derivative test (iter %d): have %.2f, %.2f want %.2f",
+				k, deriv, derivs[k], expected[k])
+		}
+	}
+
+	coefs := y.Coefficients()
+	for k := 0; k < order; k++ {
+		coef, err := y.Coefficient(k)
+		if err != nil {
+			t.Errorf("unexpected error on coefficient %d: %v", k, err)
+		}
+
+		if coef != coefs[k] || coef != expected[k]/factorial(k) {
+			t.Errorf("failed on coefficient test (iter %d): have %.2f, %.2f want %.2f",
+				k, coef, coefs[k], expected[k]/factorial(k))
+		}
+	}
+
+	for _, k := range []int{-1, order, order + 1} {
+		if _, err := y.Derivative(k); !errors.Is(err, ErrIndexOutOfRange) {
+			t.Errorf("expected out of range error on derivative %d: have %v", k, err)
+		}
+
+		if _, err := y.Coefficient(k); !errors.Is(err, ErrIndexOutOfRange) {
+			t.Errorf("expected out of range error on coefficient %d: have %v", k, err)
+		}
+	}
+}
+
+func TestMismatchedOrders(t *testing.T) {
+	x := NewGDual(5, 2.0, true)
+	y := NewGDual(3, 3.0, true)
+
+	// mismatched orders are truncated to the smaller order
+	z := x.Mul(y)
+	if z.Order() != 3 {
+		t.Errorf("order mismatch: have %d want %d", z.Order(), 3)
+	}
+
+	if _, err := x.AddE(y); !errors.Is(err, ErrOrderMismatch) {
+		t.Errorf("expected order mismatch error on add: have %v", err)
+	}
+
+	if _, err := x.SubE(y); !errors.Is(err, ErrOrderMismatch) {
+		t.Errorf("expected order mismatch error on sub: have %v", err)
+	}
+
+	if _, err := x.MulE(y); !errors.Is(err, ErrOrderMismatch) {
+		t.Errorf("expected order mismatch error on mul: have %v", err)
+	}
+}
+
+func TestSingularDiv(t *testing.T) {
+	order := 4
+
+	x := NewGDual(order, 0.0, true)
+	one := NewGDual(order, 1.0, false)
+
+	if _, err := one.DivE(x); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("expected division by zero error on div: have %v", err)
+	}
+
+	if _, err := x.InvE(); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("expected division by zero error on inv: have %v", err)
+	}
+
+	// f(0.0) = sin(x) / x
+	y, err := x.Sin().DivLimit(x)
+	if err != nil {
+		t.Fatalf("unexpected error on limit: %v", err)
+	}
+
+	if y.Order() != order-1 {
+		t.Errorf("order mismatch: have %d want %d", y.Order(), order-1)
+	}
+
+	if y.Value() != 1.0 {
+		t.Errorf("failed on limit test: have %.2f want %.2f", y.Value(), 1.0)
+	}
+
+	// a small seed is still a divisor: f(1e-15) = 1 / x
+	small := NewGDual(order, 1e-15, true)
+
+	inv, err := small.InvE()
+	if err != nil {
+		t.Fatalf("unexpected error on small inv: %v", err)
+	}
+
+	if !almostEqual(inv.Value()/1e15, 1.0) {
+		t.Errorf("failed on small inv test: have %v want %v", inv.Value(), 1e15)
+	}
+
+	if _, err := one.DivE(small); err != nil {
+		t.Errorf("unexpected error on small div: %v", err)
+	}
+}
+
+func TestScalar(t *testing.T) {
+	order := 10
+	inp := 3.0
+
+	x := NewGDual(order, inp, true)
+	c := NewGDual(order, 2.5, false)
+
+	tests := []struct {
+		name     string
+		have     *GDual
+		expected *GDual
+	}{
+		{"add", x.Sin().AddScalar(2.5), x.Sin().Add(c)},
+		{"sub", x.Sin().SubScalar(2.5), x.Sin().Sub(c)},
+		{"mul", x.Sin().MulScalar(2.5), x.Sin().Mul(c)},
+		{"div", x.Sin().DivScalar(2.5), x.Sin().Div(c)},
+		{"scalar sub", x.Sin().ScalarSub(2.5), c.Sub(x.Sin())},
+		{"scalar div", x.Sin().ScalarDiv(2.5), c.Div(x.Sin())},
+	}
+
+	for i, tt := range tests {
+		for k := 0; k < order; k++ {
+			if !almostEqual(tt.have.mat.get(k), tt.expected.mat.get(k)) {
+				t.Errorf("value mismatch on %s test %d (col %d): have %v want %v",
+					tt.name, i, k, tt.have.mat.get(k), tt.expected.mat.get(k))
+			}
+		}
+
+		if !tt.have.IsVariable() {
+			t.Errorf("expected variable on %s test %d", tt.name, i)
+		}
+	}
+
+	// f(3.0) = 4x^2 / (1 - x)^3, as in TestComplex
+	expected := []float64{
+		-4.5, 3.75, -2.75, 1.875,
+		-1.21875, 0.765625, -0.46875,
+		0.28125, -0.166015625,
+	}
+
+	y := x.Pow(2).MulScalar(4).Div(x.ScalarSub(1).Pow(3))
+	for i := 0; i < len(expected); i++ {
+		if y.mat.get(i) != expected[i] {
+			t.Errorf("value mismatch on scalar complex test (col %d): have %v want %v",
+				i, y.mat.get(i), expected[i])
+		}
+	}
+
+	// the scalar only changes the value of the other backends too
+	m := NewGDualFrom(NewMatrix, order, inp, true).AddScalar(1).MulScalar(2)
+	for k, want := range []float64{8, 2, 0, 0} {
+		if have := m.mat.get(k); have != want {
+			t.Errorf("value mismatch on scalar matrix test (col %d): have %v want %v", k, have, want)
+		}
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/sencha-dev/go-gdual
 
-go 1.16
+go 1.18
@@ -0,0 +1,238 @@
+/*
+
+closed intervals of float64, with outward rounding.
+
+every operation on an Interval returns an interval that contains the
+exact result for every pair of points in its inputs. float64 rounding
+can only ever widen the result: each bound is computed in round to
+nearest, and then moved one float away from the middle (with
+math.Nextafter), which is always at least as far as the exact bound.
+
+the elementary functions of the math package aren't correctly rounded,
+so their bounds are moved by libraryUlps floats instead. this assumes
+math is accurate to within a unit in the last place, which holds for
+every function used here on amd64 and arm64, but isn't something the
+package documents.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"math"
+)
+
+// how far the results of math.Exp, math.Log, math.Sin and math.Cos are widened
+const libraryUlps = 2
+
+type Interval struct {
+	Lo, Hi float64
+}
+
+// NewInterval creates the interval [lo, hi]
+func NewInterval(lo, hi float64) Interval {
+	return Interval{Lo: lo, Hi: hi}
+}
+
+// Point creates an interval holding just x. decimal constants like 0.1
+// aren't exact in binary, so for them use NewInterval with the floats
+// on either side instead.
+func Point(x float64) Interval {
+	return Interval{Lo: x, Hi: x}
+}
+
+func entire() Interval {
+	return Interval{Lo: math.Inf(-1), Hi: math.Inf(1)}
+}
+
+/* utility functions */
+
+func down(x float64) float64 {
+	return math.Nextafter(x, math.Inf(-1))
+}
+
+func up(x float64) float64 {
+	return math.Nextafter(x, math.Inf(1))
+}
+
+// moves both bounds n floats outward
+func (a Interval) widen(n int) Interval {
+	for i := 0; i < n; i++ {
+		a.Lo, a.Hi = down(a.Lo), up(a.Hi)
+	}
+
+	return a
+}
+
+func (a Interval) String() string {
+	return fmt.Sprintf("[%v, %v]", a.Lo, a.Hi)
+}
+
+// Mid returns the midpoint of the interval, rounded to the nearest float
+func (a Interval) Mid() float64 {
+	return a.Lo/2 + a.Hi/2
+}
+
+// Width returns the width of the interval, rounded up
+func (a Interval) Width() float64 {
+	return up(a.Hi - a.Lo)
+}
+
+func (a Interval) Contains(x float64) bool {
+	return a.Lo <= x && x <= a.Hi
+}
+
+// Subset reports whether every point of a is in b
+func (a Interval) Subset(b Interval) bool {
+	return b.Lo <= a.Lo && a.Hi <= b.Hi
+}
+
+func (a Interval) containsZero() bool {
+	return a.Contains(0)
+}
+
+/* arithmetic */
+
+func (a Interval) Add(b Interval) Interval {
+	return Interval{Lo: down(a.Lo + b.Lo), Hi: up(a.Hi + b.Hi)}
+}
+
+func (a Interval) Sub(b Interval) Interval {
+	return Interval{Lo: down(a.Lo - b.Hi), Hi: up(a.Hi - b.Lo)}
+}
+
+func (a Interval) Neg() Interval {
+	return Interval{Lo: -a.Hi, Hi: -a.Lo}
+}
+
+// an infinite bound stands for points that are arbitrarily large, but
+// still finite, so a bound times zero is zero, where float64 gives NaN
+func mulBounds(x, y float64) float64 {
+	if x == 0 || y == 0 {
+		return 0
+	}
+
+	return x * y
+}
+
+func (a Interval) Mul(b Interval) Interval {
+	p := [4]float64{mulBounds(a.Lo, b.Lo), mulBounds(a.Lo, b.Hi), mulBounds(a.Hi, b.Lo), mulBounds(a.Hi, b.Hi)}
+
+	lo, hi := p[0], p[0]
+	for _, x := range p[1:] {
+		lo = math.Min(lo, x)
+		hi = math.Max(hi, x)
+	}
+
+	return Interval{Lo: down(lo), Hi: up(hi)}
+}
+
+// Div returns a / b, which is the whole real line when b contains zero
+func (a Interval) Div(b Interval) Interval {
+	if b.containsZero() {
+		return entire()
+	}
+
+	return a.Mul(b.inv())
+}
+
+func (a Interval) inv() Interval {
+	if a.containsZero() {
+		return entire()
+	}
+
+	return Interval{Lo: down(1 / a.Hi), Hi: up(1 / a.Lo)}
+}
+
+// integer powers, n >= 0. even powers are never negative, which a product
+// of intervals like [-1, 1] * [-1, 1] = [-1, 1] wouldn't know.
+func (a Interval) pow(n int) Interval {
+	if n == 0 {
+		return Point(1)
+	}
+
+	// each bound to the n-th power, by squaring
+	bound := func(x float64) Interval {
+		out, base := Point(1), Point(x)
+		for k := n; k > 0; k >>= 1 {
+			if k&1 == 1 {
+				out = out.Mul(base)
+			}
+			base = base.Mul(base)
+		}
+
+		return out
+	}
+
+	lo, hi := bound(a.Lo), bound(a.Hi)
+	switch {
+	case n%2 == 1 || a.Lo >= 0:
+		return Interval{Lo: lo.Lo, Hi: hi.Hi}
+	case a.Hi <= 0:
+		return Interval{Lo: hi.Lo, Hi: lo.Hi}
+	default:
+		return Interval{Lo: 0, Hi: math.Max(lo.Hi, hi.Hi)}
+	}
+}
+
+/* elementary functions */
+
+// the functions below are monotonic, so they only need the bounds
+
+func (a Interval) exp() Interval {
+	out := Interval{Lo: math.Exp(a.Lo), Hi: math.Exp(a.Hi)}.widen(libraryUlps)
+	out.Lo = math.Max(out.Lo, 0)
+
+	return out
+}
+
+// log of the positive part of a, which the caller has to check
+func (a Interval) log() Interval {
+	return Interval{Lo: math.Log(a.Lo), Hi: math.Log(a.Hi)}.widen(libraryUlps)
+}
+
+// math.Sqrt is correctly rounded, so one float is enough
+func (a Interval) sqrt() Interval {
+	out := Interval{Lo: math.Sqrt(math.Max(a.Lo, 0)), Hi: math.Sqrt(a.Hi)}.widen(1)
+	out.Lo = math.Max(out.Lo, 0)
+
+	return out
+}
+
+// sin and cos take the values at both bounds, plus any extremum inside.
+// the extrema are at (m + shift) * π, a maximum for even m and a minimum
+// for odd m. π isn't exact, so the search for m is widened slightly,
+// which at worst includes an extremum that's just outside.
+func (a Interval) periodic(fn func(float64) float64, shift float64) Interval {
+	if a.Width() >= 2*math.Pi {
+		return Interval{Lo: -1, Hi: 1}
+	}
+
+	f0, f1 := fn(a.Lo), fn(a.Hi)
+	out := Interval{Lo: math.Min(f0, f1), Hi: math.Max(f0, f1)}.widen(libraryUlps)
+
+	lo, hi := a.Lo/math.Pi-shift, a.Hi/math.Pi-shift
+	lo -= 1e-14 * (1 + math.Abs(lo))
+	hi += 1e-14 * (1 + math.Abs(hi))
+	for m := math.Ceil(lo); m <= math.Floor(hi); m++ {
+		if math.Mod(m, 2) == 0 {
+			out.Hi = 1
+		} else {
+			out.Lo = -1
+		}
+	}
+
+	out.Lo = math.Max(out.Lo, -1)
+	out.Hi = math.Min(out.Hi, 1)
+
+	return out
+}
+
+func (a Interval) sin() Interval {
+	return a.periodic(math.Sin, 0.5)
+}
+
+func (a Interval) cos() Interval {
+	return a.periodic(math.Cos, 0)
+}
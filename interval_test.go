@@ -0,0 +1,86 @@
+package gdual
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestIntervalArithmetic(t *testing.T) {
+	rng := rand.New(rand.NewSource(16))
+
+	tests := []struct {
+		name     string
+		have     func(a, b Interval) Interval
+		expected func(a, b *big.Rat) *big.Rat
+	}{
+		{"add", Interval.Add, func(a, b *big.Rat) *big.Rat { return new(big.Rat).Add(a, b) }},
+		{"sub", Interval.Sub, func(a, b *big.Rat) *big.Rat { return new(big.Rat).Sub(a, b) }},
+		{"mul", Interval.Mul, func(a, b *big.Rat) *big.Rat { return new(big.Rat).Mul(a, b) }},
+		{"div", Interval.Div, func(a, b *big.Rat) *big.Rat { return new(big.Rat).Quo(a, b) }},
+	}
+
+	// the exact result of two floats has to be in the interval of their points
+	for i, tt := range tests {
+		for n := 0; n < 1000; n++ {
+			x, y := rng.NormFloat64()*100, rng.NormFloat64()/3
+			have := tt.have(Point(x), Point(y))
+
+			expected := tt.expected(new(big.Rat).SetFloat64(x), new(big.Rat).SetFloat64(y))
+			lo, hi := new(big.Rat).SetFloat64(have.Lo), new(big.Rat).SetFloat64(have.Hi)
+			if lo.Cmp(expected) > 0 || hi.Cmp(expected) < 0 {
+				t.Errorf("value mismatch on %s test %d (%v, %v): %v doesn't contain %s",
+					tt.name, i, x, y, have, expected.FloatString(20))
+			}
+
+			if have.Width() > 1e-14*math.Max(1, math.Abs(have.Lo)) {
+				t.Errorf("value mismatch on %s test %d (%v, %v): %v is too wide", tt.name, i, x, y, have)
+			}
+		}
+	}
+
+	if have := Point(1).Div(NewInterval(-1, 1)); !math.IsInf(have.Lo, -1) || !math.IsInf(have.Hi, 1) {
+		t.Errorf("value mismatch on division by zero: have %v want the real line", have)
+	}
+
+	// zero times an unbounded interval is still zero, not NaN
+	for _, b := range []Interval{entire(), NewInterval(0, math.Inf(1)), NewInterval(math.Inf(-1), -1)} {
+		if have := Point(0).Mul(b); !have.Contains(0) || have.Width() > 1e-300 {
+			t.Errorf("value mismatch on [0, 0] * %v: have %v want [0, 0]", b, have)
+		}
+	}
+}
+
+func TestIntervalFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		have     Interval
+		expected Interval
+	}{
+		// enclosures that are tight up to rounding
+		{"sin over a maximum", NewInterval(1, 2).sin(), NewInterval(math.Sin(1), 1)},
+		{"sin over both extrema", NewInterval(-2, 2).sin(), NewInterval(-1, 1)},
+		{"sin monotonic", NewInterval(-0.5, 0.5).sin(), NewInterval(math.Sin(-0.5), math.Sin(0.5))},
+		{"cos over a minimum", NewInterval(3, 4).cos(), NewInterval(-1, math.Cos(4))},
+		{"cos far away", NewInterval(1e6+0.5, 1e6+1).cos(), NewInterval(math.Cos(1e6+1), math.Cos(1e6+0.5))},
+		{"cos far away over a maximum", NewInterval(1e6, 1e6+1).cos(), NewInterval(math.Cos(1e6+1), 1)},
+		{"cos over a period", NewInterval(0, 7).cos(), NewInterval(-1, 1)},
+		{"exp", NewInterval(-1, 2).exp(), NewInterval(math.Exp(-1), math.Exp(2))},
+		{"log", NewInterval(0.5, 3).log(), NewInterval(math.Log(0.5), math.Log(3))},
+		{"sqrt", NewInterval(0, 2).sqrt(), NewInterval(0, math.Sqrt2)},
+		{"even power", NewInterval(-2, 1).pow(4), NewInterval(0, 16)},
+		{"even power below zero", NewInterval(-3, -2).pow(2), NewInterval(4, 9)},
+		{"odd power", NewInterval(-2, 1).pow(3), NewInterval(-8, 1)},
+	}
+
+	for i, tt := range tests {
+		if !tt.expected.Subset(tt.have) {
+			t.Errorf("value mismatch on %s test %d: %v doesn't contain %v", tt.name, i, tt.have, tt.expected)
+		}
+
+		if !tt.have.Subset(tt.expected.widen(4)) {
+			t.Errorf("value mismatch on %s test %d: %v is wider than %v", tt.name, i, tt.have, tt.expected)
+		}
+	}
+}
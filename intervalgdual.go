@@ -0,0 +1,221 @@
+/*
+
+generalized dual numbers with interval coefficients.
+
+IntervalGDual is GDual with Interval coefficients, for verified
+computation. each coefficient is a guaranteed enclosure of the exact
+Taylor coefficient, whatever float64 rounding happened on the way, and
+for every seed in the interval the dual number was created with.
+
+like BigGDual, operations that can't be enclosed (dividing by an
+interval containing zero, or Log of one that reaches zero) keep the
+error instead, and every result computed from it carries it along.
+
+an Interval isn't a Field (see scalar.go): every operation has to round
+its bounds outwards, and a few of them can fail, neither of which the
+arithmetic operators of GDualOf can do. so, like BigGDual, it has a
+matrix and a dual number of its own, with the same API.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+)
+
+type IntervalGDual struct {
+	mat      *IntervalUpperTriToeplitz
+	variable bool
+	err      error
+}
+
+func NewIntervalGDual(order int, seed Interval, variable bool) *IntervalGDual {
+	mat := NewIntervalUpperTriToeplitz(order)
+	mat.Fill(0, seed)
+	if variable {
+		mat.Fill(1, Point(1))
+	}
+
+	gdual := &IntervalGDual{
+		mat:      mat,
+		variable: variable,
+	}
+
+	return gdual
+}
+
+func importIntervalGDual(mat *IntervalUpperTriToeplitz, variable bool, err error) *IntervalGDual {
+	if err != nil {
+		mat = NewIntervalUpperTriToeplitz(mat.order)
+	}
+
+	gdual := &IntervalGDual{
+		mat:      mat,
+		variable: variable,
+		err:      err,
+	}
+
+	return gdual
+}
+
+// applies a function of the matrix, unless g already carries an error
+func (g *IntervalGDual) apply(fn func(*IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error)) *IntervalGDual {
+	if g.err != nil {
+		return g
+	}
+
+	mat, err := fn(g.mat)
+	if err != nil {
+		return importIntervalGDual(g.mat, g.variable, err)
+	}
+
+	return importIntervalGDual(mat, g.variable, nil)
+}
+
+// applies a function of both matrices, keeping the first error of either
+func (g *IntervalGDual) combine(inp *IntervalGDual, fn func(a, b *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error)) *IntervalGDual {
+	variable := g.variable || inp.variable
+	if g.err != nil {
+		return importIntervalGDual(g.mat, variable, g.err)
+	}
+
+	if inp.err != nil {
+		return importIntervalGDual(g.mat, variable, inp.err)
+	}
+
+	mat, err := fn(g.mat, inp.mat)
+	if err != nil {
+		return importIntervalGDual(g.mat, variable, err)
+	}
+
+	return importIntervalGDual(mat, variable, nil)
+}
+
+/* accessors */
+
+// Err returns the first error in the computation of g, if any
+func (g *IntervalGDual) Err() error {
+	return g.err
+}
+
+func (g *IntervalGDual) Order() int {
+	return g.mat.order
+}
+
+// Value returns an enclosure of f(x0), for every x0 in the seed
+func (g *IntervalGDual) Value() Interval {
+	return g.mat.Coefficient(0)
+}
+
+// Coefficient returns an enclosure of the k-th Taylor coefficient, f^(k)(x0) / k!
+func (g *IntervalGDual) Coefficient(k int) (Interval, error) {
+	if k < 0 || k >= g.mat.order {
+		return Interval{}, fmt.Errorf("%w: coefficient %d of order %d", ErrIndexOutOfRange, k, g.mat.order)
+	}
+
+	return g.mat.Coefficient(k), nil
+}
+
+// Coefficients returns an enclosure of every Taylor coefficient
+func (g *IntervalGDual) Coefficients() []Interval {
+	coefs := make([]Interval, g.mat.order)
+	copy(coefs, g.mat.val)
+
+	return coefs
+}
+
+// Derivative returns an enclosure of the k-th derivative, f^(k)(x0)
+func (g *IntervalGDual) Derivative(k int) (Interval, error) {
+	coef, err := g.Coefficient(k)
+	if err != nil {
+		return Interval{}, err
+	}
+
+	for i := 2; i <= k; i++ {
+		coef = coef.Mul(Point(float64(i)))
+	}
+
+	return coef, nil
+}
+
+// Derivatives returns an enclosure of every derivative, from f(x0) up to f^(order-1)(x0)
+func (g *IntervalGDual) Derivatives() []Interval {
+	derivs := g.Coefficients()
+
+	factorial := Point(1)
+	for k := range derivs {
+		if k > 1 {
+			factorial = factorial.Mul(Point(float64(k)))
+		}
+		derivs[k] = derivs[k].Mul(factorial)
+	}
+
+	return derivs
+}
+
+/* arithmetic */
+
+func (g *IntervalGDual) Add(inp *IntervalGDual) *IntervalGDual {
+	return g.combine(inp, func(a, b *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error) {
+		return a.Add(b), nil
+	})
+}
+
+func (g *IntervalGDual) Sub(inp *IntervalGDual) *IntervalGDual {
+	return g.combine(inp, func(a, b *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error) {
+		return a.Sub(b), nil
+	})
+}
+
+func (g *IntervalGDual) Mul(inp *IntervalGDual) *IntervalGDual {
+	return g.combine(inp, func(a, b *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error) {
+		return a.Mul(b), nil
+	})
+}
+
+func (g *IntervalGDual) Div(inp *IntervalGDual) *IntervalGDual {
+	return g.combine(inp, (*IntervalUpperTriToeplitz).Div)
+}
+
+func (g *IntervalGDual) Inv() *IntervalGDual {
+	return g.apply((*IntervalUpperTriToeplitz).Inv)
+}
+
+func (g *IntervalGDual) Pow(n int) *IntervalGDual {
+	return g.apply(func(m *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error) {
+		return m.Pow(n)
+	})
+}
+
+/* elementary functions */
+
+func (g *IntervalGDual) Exp() *IntervalGDual {
+	return g.apply(func(m *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error) {
+		return m.Exp(), nil
+	})
+}
+
+func (g *IntervalGDual) Log() *IntervalGDual {
+	return g.apply((*IntervalUpperTriToeplitz).Log)
+}
+
+func (g *IntervalGDual) Sqrt() *IntervalGDual {
+	return g.apply((*IntervalUpperTriToeplitz).Sqrt)
+}
+
+func (g *IntervalGDual) Sin() *IntervalGDual {
+	return g.apply(func(m *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error) {
+		return m.Sin(), nil
+	})
+}
+
+func (g *IntervalGDual) Cos() *IntervalGDual {
+	return g.apply(func(m *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error) {
+		return m.Cos(), nil
+	})
+}
+
+func (g *IntervalGDual) Tan() *IntervalGDual {
+	return g.apply((*IntervalUpperTriToeplitz).Tan)
+}
@@ -0,0 +1,207 @@
+package gdual
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestIntervalOracle(t *testing.T) {
+	order := 15
+	prec := uint(200)
+	inp := 0.3
+
+	// every interval has to contain the (nearly exact) big.Float coefficient
+	x := NewIntervalGDual(order, Point(inp), true)
+	bx := NewBigGDual(order, prec, big.NewFloat(inp), true)
+	two := NewIntervalGDual(order, Point(2.0), false)
+	btwo := NewBigGDual(order, prec, big.NewFloat(2.0), false)
+
+	tests := []struct {
+		name     string
+		have     *IntervalGDual
+		expected *BigGDual
+	}{
+		{"exp", x.Exp(), bx.Exp()},
+		{"log", x.Log(), bx.Log()},
+		{"sqrt", x.Sqrt(), bx.Sqrt()},
+		{"sin", x.Sin(), bx.Sin()},
+		{"cos", x.Cos(), bx.Cos()},
+		{"tan", x.Tan(), bx.Tan()},
+		{"pow", x.Pow(-3), bx.Pow(-3)},
+		{"div", x.Sin().Div(two.Add(x.Pow(3))), bx.Sin().Div(btwo.Add(bx.Pow(3)))},
+		{"composed", x.Exp().Sin().Mul(x.Sqrt().Log()), bx.Exp().Sin().Mul(bx.Sqrt().Log())},
+	}
+
+	for i, tt := range tests {
+		if err := tt.have.Err(); err != nil {
+			t.Errorf("failed on %s oracle test %d: %v", tt.name, i, err)
+			continue
+		}
+
+		for k, have := range tt.have.Coefficients() {
+			expected := tt.expected.mat.get(k)
+			lo, hi := new(big.Float).SetFloat64(have.Lo), new(big.Float).SetFloat64(have.Hi)
+			if lo.Cmp(expected) > 0 || hi.Cmp(expected) < 0 {
+				t.Errorf("value mismatch on %s oracle test %d (col %d): %v doesn't contain %s",
+					tt.name, i, k, have, expected.Text('g', 20))
+			}
+
+			// the enclosure shouldn't be much wider than the rounding error
+			mid, _ := expected.Float64()
+			if have.Width() > 1e-11*math.Max(1, math.Abs(mid)) {
+				t.Errorf("value mismatch on %s oracle test %d (col %d): %v is too wide",
+					tt.name, i, k, have)
+			}
+		}
+	}
+}
+
+func TestIntervalSeed(t *testing.T) {
+	order := 8
+	seed := NewInterval(0.9, 1.1)
+
+	// f(x) = exp(x) * sin(x), for every x in the seed
+	x := NewIntervalGDual(order, seed, true)
+	y := x.Exp().Mul(x.Sin())
+
+	for _, inp := range []float64{0.9, 0.95, 1.0, 1.05, 1.1} {
+		expected := NewGDual(order, inp, true)
+		expected = expected.Exp().Mul(expected.Sin())
+
+		for k, have := range y.Coefficients() {
+			if !have.Contains(expected.mat.get(k)) {
+				t.Errorf("value mismatch on seed %v (col %d): %v doesn't contain %v",
+					inp, k, have, expected.mat.get(k))
+			}
+		}
+	}
+}
+
+func TestTaylorModel(t *testing.T) {
+	center, radius := 0.5, 0.25
+
+	// f(x) = exp(-x) / (1 + x^2)
+	f := func(x float64) float64 {
+		return math.Exp(-x) / (1 + x*x)
+	}
+
+	var widths []float64
+	for _, order := range []int{2, 4, 8, 12} {
+		tm, err := NewTaylorModel(order, center, radius, func(x *IntervalGDual) *IntervalGDual {
+			one := NewIntervalGDual(x.Order(), Point(1), false)
+			return x.Mul(NewIntervalGDual(x.Order(), Point(-1), false)).Exp().Div(one.Add(x.Pow(2)))
+		})
+		if err != nil {
+			t.Fatalf("failed on taylor model of order %d: %v", order, err)
+		}
+
+		bound := tm.Bound()
+		for i := 0; i <= 100; i++ {
+			inp := center - radius + 2*radius*float64(i)/100
+
+			have, err := tm.Eval(inp)
+			if err != nil {
+				t.Errorf("failed on taylor model of order %d (input %v): %v", order, inp, err)
+				continue
+			}
+
+			expected := f(inp)
+			if !have.Contains(expected) || !bound.Contains(expected) {
+				t.Errorf("value mismatch on taylor model of order %d (input %v): %v and %v don't contain %v",
+					order, inp, have, bound, expected)
+			}
+		}
+
+		widths = append(widths, tm.Remainder().Width())
+	}
+
+	// the remainder shrinks with the order
+	for i := 1; i < len(widths); i++ {
+		if widths[i] >= widths[i-1] {
+			t.Errorf("value mismatch on taylor model remainders: %v doesn't shrink", widths)
+		}
+	}
+}
+
+func TestIntervalErrors(t *testing.T) {
+	order := 5
+
+	x := NewIntervalGDual(order, NewInterval(-0.5, 0.5), true)
+	one := NewIntervalGDual(order, Point(1), false)
+
+	tests := []struct {
+		name     string
+		have     *IntervalGDual
+		expected error
+	}{
+		{"inv", x.Inv(), ErrDivisionByZero},
+		{"div", one.Div(x), ErrDivisionByZero},
+		{"log", x.Log(), ErrDomain},
+		{"sqrt", x.Sqrt(), ErrDomain},
+		{"tan", x.Add(one).Mul(NewIntervalGDual(order, Point(2), false)).Tan(), ErrDivisionByZero},
+		{"propagated", x.Log().Exp().Add(one), ErrDomain},
+		{"none", x.Add(one).Sqrt().Log().Pow(2), nil},
+	}
+
+	for i, tt := range tests {
+		if !errors.Is(tt.have.Err(), tt.expected) {
+			t.Errorf("error mismatch on %s test %d: have %v want %v",
+				tt.name, i, tt.have.Err(), tt.expected)
+		}
+	}
+
+	_, err := NewTaylorModel(order, 0.0, 1.0, func(x *IntervalGDual) *IntervalGDual {
+		return x.Add(one).Log()
+	})
+	if !errors.Is(err, ErrDomain) {
+		t.Errorf("error mismatch on taylor model: have %v want %v", err, ErrDomain)
+	}
+
+	tm, _ := NewTaylorModel(order, 0.0, 1.0, (*IntervalGDual).Exp)
+	if _, err := tm.Eval(2.0); !errors.Is(err, ErrDomain) {
+		t.Errorf("error mismatch on taylor model eval: have %v want %v", err, ErrDomain)
+	}
+}
+
+func TestIntervalUnbounded(t *testing.T) {
+	order := 3
+
+	// f(x) = x * y^2 with y = Y + x, where Y^2 overflows. the coefficients
+	// are [0, Y^2, 2Y], which the products of zero with the infinite bounds
+	// of Y^2 mustn't turn into NaN
+	x := NewIntervalGDual(order, Point(0), true)
+	y := NewIntervalGDual(order, NewInterval(-1e308, 1e308), true)
+	z := x.Mul(y.Mul(y))
+
+	if err := z.Err(); err != nil {
+		t.Fatalf("failed on unbounded test: %v", err)
+	}
+
+	// the series at the points Y = 0, 1 and 1e308
+	for _, point := range []float64{0, 1, 1e308} {
+		expected := []float64{0, point * point, 2 * point}
+		for k, coef := range z.Coefficients() {
+			if math.IsNaN(coef.Lo) || math.IsNaN(coef.Hi) || !coef.Contains(expected[k]) {
+				t.Errorf("value mismatch on unbounded test at %g (col %d): %v doesn't contain %g",
+					point, k, coef, expected[k])
+			}
+		}
+	}
+}
+
+func TestIntervalPowMinInt(t *testing.T) {
+	// -math.MinInt doesn't fit an int, and 2^(-2^63) underflows to zero
+	y := NewIntervalGDual(3, Point(2), true).Pow(math.MinInt)
+
+	if err := y.Err(); err != nil {
+		t.Fatalf("failed on pow test: %v", err)
+	}
+
+	for k, coef := range y.Coefficients() {
+		if math.IsNaN(coef.Lo) || math.IsNaN(coef.Hi) || !coef.Contains(0) {
+			t.Errorf("value mismatch on pow test (col %d): %v doesn't contain 0", k, coef)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+/*
+
+upper triangular Toeplitz matrices of intervals.
+
+the same matrix as UpperTriToeplitz, with the same recurrences for the
+elementary functions (see elementary.go), but every coefficient is an
+Interval. every step of a recurrence is an enclosure, so each
+coefficient of the result contains the exact Taylor coefficient, for
+every point of the intervals the inputs were seeded with.
+
+the recurrences divide by the value of the series, and take the
+elementary function of it, so they need that value to stay away from
+the edge of the domain: dividing by an interval that contains zero
+returns ErrDivisionByZero, and Log or Sqrt of one that reaches zero
+returns ErrDomain.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"math"
+)
+
+type IntervalUpperTriToeplitz struct {
+	order int
+	val   []Interval
+}
+
+func NewIntervalUpperTriToeplitz(order int) *IntervalUpperTriToeplitz {
+	mat := &IntervalUpperTriToeplitz{
+		order: order,
+		val:   make([]Interval, order),
+	}
+
+	return mat
+}
+
+/* utility functions */
+
+func (m *IntervalUpperTriToeplitz) get(i int) Interval {
+	if i < 0 || i >= m.order {
+		return Interval{}
+	}
+
+	return m.val[i]
+}
+
+func (m *IntervalUpperTriToeplitz) set(i int, val Interval) {
+	if i < 0 || i >= m.order {
+		return
+	}
+
+	m.val[i] = val
+}
+
+func (m *IntervalUpperTriToeplitz) Order() int {
+	return m.order
+}
+
+func (m *IntervalUpperTriToeplitz) Coefficient(i int) Interval {
+	return m.get(i)
+}
+
+func (m *IntervalUpperTriToeplitz) Fill(diagonal int, val Interval) {
+	// fill the given upper diagonal of the matrix
+	m.set(diagonal, val)
+}
+
+func (m *IntervalUpperTriToeplitz) Reset(val Interval) {
+	for i := 0; i < m.order; i++ {
+		m.set(i, val)
+	}
+}
+
+func (m *IntervalUpperTriToeplitz) Copy() *IntervalUpperTriToeplitz {
+	copy := NewIntervalUpperTriToeplitz(m.order)
+	for i := 0; i < m.order; i++ {
+		copy.set(i, m.get(i))
+	}
+
+	return copy
+}
+
+/* element-wise matrix operations */
+
+func (m *IntervalUpperTriToeplitz) ElementAdd(val Interval) {
+	for i := 0; i < m.order; i++ {
+		m.val[i] = m.val[i].Add(val)
+	}
+}
+
+func (m *IntervalUpperTriToeplitz) ElementSub(val Interval) {
+	for i := 0; i < m.order; i++ {
+		m.val[i] = m.val[i].Sub(val)
+	}
+}
+
+func (m *IntervalUpperTriToeplitz) ElementMul(val Interval) {
+	for i := 0; i < m.order; i++ {
+		m.val[i] = m.val[i].Mul(val)
+	}
+}
+
+func (m *IntervalUpperTriToeplitz) ElementDiv(val Interval) {
+	for i := 0; i < m.order; i++ {
+		m.val[i] = m.val[i].Div(val)
+	}
+}
+
+/* matrix operations */
+
+// binary operations are truncated to the smaller order, as for the float64 matrix
+
+func (m *IntervalUpperTriToeplitz) Add(inp *IntervalUpperTriToeplitz) *IntervalUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewIntervalUpperTriToeplitz(order)
+	for i := 0; i < order; i++ {
+		out.val[i] = m.get(i).Add(inp.get(i))
+	}
+
+	return out
+}
+
+func (m *IntervalUpperTriToeplitz) Sub(inp *IntervalUpperTriToeplitz) *IntervalUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewIntervalUpperTriToeplitz(order)
+	for i := 0; i < order; i++ {
+		out.val[i] = m.get(i).Sub(inp.get(i))
+	}
+
+	return out
+}
+
+func (m *IntervalUpperTriToeplitz) Mul(inp *IntervalUpperTriToeplitz) *IntervalUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewIntervalUpperTriToeplitz(order)
+	for i := 0; i < order; i++ {
+		out.val[i] = m.convolve(m, inp, i, 0, i, 0)
+	}
+
+	return out
+}
+
+// b_0 = 1 / a_0, b_k = -(1 / a_0) * Σ a_j*b_{k-j}, see Inv in matrix.go
+func (m *IntervalUpperTriToeplitz) Inv() (*IntervalUpperTriToeplitz, error) {
+	inv := NewIntervalUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return inv, nil
+	}
+
+	a := m.get(0)
+	if a.containsZero() {
+		return nil, fmt.Errorf("%w: inverse of %v", ErrDivisionByZero, a)
+	}
+
+	inv.val[0] = a.inv()
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(m, inv, k, 1, k, 0)
+		inv.val[k] = sum.Neg().Div(a)
+	}
+
+	return inv, nil
+}
+
+func (m *IntervalUpperTriToeplitz) Div(inp *IntervalUpperTriToeplitz) (*IntervalUpperTriToeplitz, error) {
+	inv, err := inp.Inv()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Mul(inv), nil
+}
+
+/*
+integer powers, by squaring. Miller's recurrence (see PowReal in
+elementary.go) divides by the value, which makes for much wider
+coefficients with intervals, so unlike the other matrices it isn't
+used here. the value itself is taken as the power of an interval, which
+knows that even powers aren't negative.
+*/
+func (m *IntervalUpperTriToeplitz) Pow(n int) (*IntervalUpperTriToeplitz, error) {
+	if n < 0 {
+		inv, err := m.Inv()
+		if err != nil {
+			return nil, err
+		}
+
+		// -n overflows for math.MinInt, which is one more than -math.MaxInt
+		if n == math.MinInt {
+			out, _ := inv.Pow(math.MaxInt)
+
+			return out.Mul(inv), nil
+		}
+
+		return inv.Pow(-n)
+	}
+
+	out := NewIntervalUpperTriToeplitz(m.order)
+	out.Fill(0, Point(1))
+
+	base := m
+	for k := n; k > 0; {
+		if k&1 == 1 {
+			out = out.Mul(base)
+		}
+
+		k >>= 1
+		if k > 0 {
+			base = base.Mul(base)
+		}
+	}
+
+	out.Fill(0, m.get(0).pow(n))
+
+	return out, nil
+}
+
+/* elementary functions */
+
+// Σ a_{j+off} * b_{k-j}, for j = from..to
+func (m *IntervalUpperTriToeplitz) convolve(a, b *IntervalUpperTriToeplitz, k, from, to, off int) Interval {
+	var sum Interval
+	for j := from; j <= to; j++ {
+		sum = sum.Add(a.get(j + off).Mul(b.get(k - j)))
+	}
+
+	return sum
+}
+
+// the derivative of the series, truncated to the same order
+func (m *IntervalUpperTriToeplitz) derivative() *IntervalUpperTriToeplitz {
+	out := NewIntervalUpperTriToeplitz(m.order)
+	for k := 1; k < m.order; k++ {
+		out.val[k-1] = m.get(k).Mul(Point(float64(k)))
+	}
+
+	return out
+}
+
+// b' = b * a'  =>  b_k = 1/k * Σ j*a_j*b_{k-j}
+func (m *IntervalUpperTriToeplitz) Exp() *IntervalUpperTriToeplitz {
+	out := NewIntervalUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return out
+	}
+
+	da := m.derivative()
+	out.set(0, m.get(0).exp())
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(da, out, k, 1, k, -1)
+		out.val[k] = sum.Div(Point(float64(k)))
+	}
+
+	return out
+}
+
+// a * b' = a'  =>  b_k = (a_k - 1/k * Σ j*b_j*a_{k-j}) / a_0
+func (m *IntervalUpperTriToeplitz) Log() (*IntervalUpperTriToeplitz, error) {
+	out := NewIntervalUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return out, nil
+	}
+
+	a := m.get(0)
+	if a.Lo <= 0 {
+		return nil, fmt.Errorf("%w: log(%v)", ErrDomain, a)
+	}
+
+	// db holds the derivative of out, as far as it's known
+	db := NewIntervalUpperTriToeplitz(m.order)
+
+	out.set(0, a.log())
+	for k := 1; k < m.order; k++ {
+		fk := Point(float64(k))
+
+		sum := m.convolve(db, m, k, 1, k-1, -1).Div(fk)
+		out.val[k] = m.get(k).Sub(sum).Div(a)
+		db.val[k-1] = out.get(k).Mul(fk)
+	}
+
+	return out, nil
+}
+
+// b * b = a  =>  b_k = (a_k - Σ b_j*b_{k-j}) / 2b_0
+func (m *IntervalUpperTriToeplitz) Sqrt() (*IntervalUpperTriToeplitz, error) {
+	out := NewIntervalUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return out, nil
+	}
+
+	a := m.get(0)
+	if a.Lo < 0 || (a.Lo == 0 && m.order > 1) {
+		return nil, fmt.Errorf("%w: sqrt(%v)", ErrDomain, a)
+	}
+
+	b := a.sqrt()
+	out.set(0, b)
+
+	twoB := b.Mul(Point(2))
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(out, out, k, 1, k-1, 0)
+		out.val[k] = m.get(k).Sub(sum).Div(twoB)
+	}
+
+	return out, nil
+}
+
+// s' = c * a' and c' = -s * a'
+func (m *IntervalUpperTriToeplitz) sinCos() (*IntervalUpperTriToeplitz, *IntervalUpperTriToeplitz) {
+	sin := NewIntervalUpperTriToeplitz(m.order)
+	cos := NewIntervalUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return sin, cos
+	}
+
+	da := m.derivative()
+	sin.set(0, m.get(0).sin())
+	cos.set(0, m.get(0).cos())
+	for k := 1; k < m.order; k++ {
+		fk := Point(float64(k))
+
+		sin.val[k] = m.convolve(da, cos, k, 1, k, -1).Div(fk)
+		cos.val[k] = m.convolve(da, sin, k, 1, k, -1).Neg().Div(fk)
+	}
+
+	return sin, cos
+}
+
+func (m *IntervalUpperTriToeplitz) Sin() *IntervalUpperTriToeplitz {
+	sin, _ := m.sinCos()
+
+	return sin
+}
+
+func (m *IntervalUpperTriToeplitz) Cos() *IntervalUpperTriToeplitz {
+	_, cos := m.sinCos()
+
+	return cos
+}
+
+// tan = sin / cos, which fails where the cosine might be zero
+func (m *IntervalUpperTriToeplitz) Tan() (*IntervalUpperTriToeplitz, error) {
+	sin, cos := m.sinCos()
+
+	return sin.Div(cos)
+}
@@ -15,23 +15,39 @@ where N is the order of the matrix.
 
 package gdual
 
+import (
+	"fmt"
+	"math"
+)
+
 // square, upper triangular Toeplitz matrix
-type UpperTriToeplitz struct {
+type UpperTriToeplitzOf[T Field] struct {
 	order int
-	val   []float64
+	val   []T
+	tmp   []*UpperTriToeplitzOf[T] // scratch space for the destination operations
+	ws    *WorkspaceOf[T]          // the workspace it belongs to, if any
 }
 
+// UpperTriToeplitz is a matrix of float64, the default element type
+type UpperTriToeplitz = UpperTriToeplitzOf[float64]
+
+// NewUpperTriToeplitz creates a matrix of float64, the default element type
 func NewUpperTriToeplitz(order int) *UpperTriToeplitz {
-	mat := &UpperTriToeplitz{
+	return NewUpperTriToeplitzOf[float64](order)
+}
+
+// NewUpperTriToeplitzOf creates a matrix of any element type in Field
+func NewUpperTriToeplitzOf[T Field](order int) *UpperTriToeplitzOf[T] {
+	mat := &UpperTriToeplitzOf[T]{
 		order: order,
-		val:   make([]float64, order),
+		val:   make([]T, order),
 	}
 
 	return mat
 }
 
-func importUpperTriToeplitz(val []float64) *UpperTriToeplitz {
-	mat := &UpperTriToeplitz{
+func importUpperTriToeplitz[T Field](val []T) *UpperTriToeplitzOf[T] {
+	mat := &UpperTriToeplitzOf[T]{
 		order: len(val),
 		val:   val,
 	}
@@ -41,35 +57,35 @@ func importUpperTriToeplitz(val []float64) *UpperTriToeplitz {
 
 /* utility functions */
 
-func (m *UpperTriToeplitz) get(i int) float64 {
-	if i > m.order {
+func (m *UpperTriToeplitzOf[T]) get(i int) T {
+	if i < 0 || i >= m.order {
 		return 0.0
 	}
 
 	return m.val[i]
 }
 
-func (m *UpperTriToeplitz) set(i int, val float64) {
-	if i > m.order {
+func (m *UpperTriToeplitzOf[T]) set(i int, val T) {
+	if i < 0 || i >= m.order {
 		return
 	}
 
 	m.val[i] = val
 }
 
-func (m *UpperTriToeplitz) Fill(diagonal int, val float64) {
+func (m *UpperTriToeplitzOf[T]) Fill(diagonal int, val T) {
 	// fill the given upper diagonal of the matrix
 	m.set(diagonal, val)
 }
 
-func (m *UpperTriToeplitz) Reset(val float64) {
+func (m *UpperTriToeplitzOf[T]) Reset(val T) {
 	for i := 0; i < m.order; i++ {
 		m.set(i, val)
 	}
 }
 
-func (m *UpperTriToeplitz) Copy() *UpperTriToeplitz {
-	copy := NewUpperTriToeplitz(m.order)
+func (m *UpperTriToeplitzOf[T]) Copy() *UpperTriToeplitzOf[T] {
+	copy := NewUpperTriToeplitzOf[T](m.order)
 
 	for i := 0; i < m.order; i++ {
 		val := m.get(i)
@@ -79,30 +95,130 @@ func (m *UpperTriToeplitz) Copy() *UpperTriToeplitz {
 	return copy
 }
 
+/*
+destination operations. like math/big, every operation also has a form
+that writes its result into the receiver and returns it, so z.SetMul(x, y)
+sets z to x * y. the receiver can be one of the inputs. its storage, and
+the scratch matrices the recurrences need, are kept and reused once they've
+grown to the order, so repeating an operation doesn't allocate (except for
+products through the FFT at orders of fftThreshold and above, which allocate
+the transform).
+the operations that return a new matrix are built on top of these.
+*/
+
+// Set sets z to a copy of x
+func (z *UpperTriToeplitzOf[T]) Set(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	if z == x {
+		return z
+	}
+
+	z.resize(x.order)
+	copy(z.val, x.val)
+
+	return z
+}
+
+// alias reports whether x and y share storage, as in math/big
+func alias[T Field](x, y []T) bool {
+	return cap(x) > 0 && cap(y) > 0 && &x[0:cap(x)][cap(x)-1] == &y[0:cap(y)][cap(y)-1]
+}
+
+// resize sets the order of z, reusing its storage when it's big enough
+func (z *UpperTriToeplitzOf[T]) resize(order int) {
+	if cap(z.val) < order {
+		z.val = make([]T, order)
+	}
+
+	z.order = order
+	z.val = z.val[:order]
+}
+
+// temp returns the i-th scratch matrix of z, zeroed and of the given order.
+// an operation and the operations it calls on z must use different indexes.
+func (z *UpperTriToeplitzOf[T]) temp(i, order int) *UpperTriToeplitzOf[T] {
+	for len(z.tmp) <= i {
+		if z.ws != nil {
+			z.tmp = append(z.tmp, z.ws.matrix(order))
+		} else {
+			z.tmp = append(z.tmp, new(UpperTriToeplitzOf[T]))
+		}
+	}
+
+	tmp := z.tmp[i]
+	tmp.resize(order)
+	tmp.Reset(0)
+
+	return tmp
+}
+
+// input returns x, or a copy of it in the i-th scratch matrix when it shares
+// storage with z, for recurrences that still read x after writing to z
+func (z *UpperTriToeplitzOf[T]) input(x *UpperTriToeplitzOf[T], i int) *UpperTriToeplitzOf[T] {
+	if !alias(z.val, x.val) {
+		return x
+	}
+
+	return z.temp(i, x.order).Set(x)
+}
+
+// result returns the matrix for the result of an allocating operation on m,
+// which comes from the workspace of m when it has one
+func (m *UpperTriToeplitzOf[T]) result() *UpperTriToeplitzOf[T] {
+	if m.ws != nil {
+		return m.ws.matrix(m.order)
+	}
+
+	return new(UpperTriToeplitzOf[T])
+}
+
+// detach drops the scratch matrices of z, so that a matrix returned by an
+// allocating operation doesn't hold on to them. a matrix from a workspace
+// keeps them until it's released.
+func (z *UpperTriToeplitzOf[T]) detach() *UpperTriToeplitzOf[T] {
+	if z.ws == nil {
+		z.tmp = nil
+	}
+
+	return z
+}
+
+// release hands z back to its workspace, along with its scratch matrices,
+// for an operation that drops its result when it fails
+func (z *UpperTriToeplitzOf[T]) release() {
+	w := z.ws
+	if w == nil {
+		return
+	}
+
+	w.releaseTemps(z)
+	z.ws = nil
+	w.mats[z.order] = append(w.mats[z.order], z)
+}
+
 /* element-wise matrix operations */
 
-func (m *UpperTriToeplitz) ElementAdd(val float64) {
+func (m *UpperTriToeplitzOf[T]) ElementAdd(val T) {
 	for i := 0; i < m.order; i++ {
 		sum := m.get(i) + val
 		m.set(i, sum)
 	}
 }
 
-func (m *UpperTriToeplitz) ElementSub(val float64) {
+func (m *UpperTriToeplitzOf[T]) ElementSub(val T) {
 	for i := 0; i < m.order; i++ {
 		difference := m.get(i) - val
 		m.set(i, difference)
 	}
 }
 
-func (m *UpperTriToeplitz) ElementMul(val float64) {
+func (m *UpperTriToeplitzOf[T]) ElementMul(val T) {
 	for i := 0; i < m.order; i++ {
 		product := m.get(i) * val
 		m.set(i, product)
 	}
 }
 
-func (m *UpperTriToeplitz) ElementDiv(val float64) {
+func (m *UpperTriToeplitzOf[T]) ElementDiv(val T) {
 	for i := 0; i < m.order; i++ {
 		quotient := m.get(i) / val
 		m.set(i, quotient)
@@ -111,101 +227,334 @@ func (m *UpperTriToeplitz) ElementDiv(val float64) {
 
 /* matrix operations */
 
-func (m *UpperTriToeplitz) Add(inp *UpperTriToeplitz) *UpperTriToeplitz {
-	out := NewUpperTriToeplitz(m.order)
-	for i := 0; i < m.order; i++ {
-		sum := m.get(i) + inp.get(i)
-		out.set(i, sum)
+/*
+binary operations between matrices of a different order are truncated
+to the smaller order, since the higher order terms of the smaller matrix
+are unknown. the checked variants (AddE, SubE, ...) return ErrOrderMismatch
+instead.
+*/
+
+func minOrder(a, b int) int {
+	if a < b {
+		return a
 	}
 
-	return out
+	return b
 }
 
-func (m *UpperTriToeplitz) Sub(inp *UpperTriToeplitz) *UpperTriToeplitz {
-	out := NewUpperTriToeplitz(m.order)
-	for i := 0; i < m.order; i++ {
-		difference := m.get(i) - inp.get(i)
-		out.set(i, difference)
+func checkOrder(a, b int) error {
+	if a != b {
+		return fmt.Errorf("%w: %d != %d", ErrOrderMismatch, a, b)
 	}
 
-	return out
+	return nil
 }
 
-func (m *UpperTriToeplitz) Mul(inp *UpperTriToeplitz) *UpperTriToeplitz {
-	out := NewUpperTriToeplitz(m.order)
-	for i := 0; i < m.order; i++ {
-		product := 0.0
-		for k := i; k >= 0; k-- {
-			product += m.get(i-k) * inp.get(k)
+func (m *UpperTriToeplitzOf[T]) Add(inp *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	return m.result().SetAdd(m, inp)
+}
+
+func (z *UpperTriToeplitzOf[T]) SetAdd(x, y *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := minOrder(x.order, y.order)
+	z.resize(order)
+	for i := 0; i < order; i++ {
+		sum := x.get(i) + y.get(i)
+		z.set(i, sum)
+	}
+
+	return z
+}
+
+func (m *UpperTriToeplitzOf[T]) Sub(inp *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	return m.result().SetSub(m, inp)
+}
+
+func (z *UpperTriToeplitzOf[T]) SetSub(x, y *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := minOrder(x.order, y.order)
+	z.resize(order)
+	for i := 0; i < order; i++ {
+		difference := x.get(i) - y.get(i)
+		z.set(i, difference)
+	}
+
+	return z
+}
+
+// high orders are multiplied with an FFT, as long as it's accurate, see fft.go
+func (m *UpperTriToeplitzOf[T]) Mul(inp *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	if minOrder(m.order, inp.order) >= fftThreshold {
+		if prod, ok := m.mulFFT(inp); ok {
+			return prod
 		}
-		out.set(i, product)
 	}
 
-	return out
+	return m.mulDirect(inp)
+}
+
+func (z *UpperTriToeplitzOf[T]) SetMul(x, y *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	if minOrder(x.order, y.order) >= fftThreshold {
+		if prod, ok := x.mulFFT(y); ok {
+			return z.Set(prod)
+		}
+	}
+
+	return z.setMulDirect(x, y)
+}
+
+func (m *UpperTriToeplitzOf[T]) mulDirect(inp *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	return m.result().setMulDirect(m, inp)
+}
+
+// z_i only depends on x_j and y_j for j <= i, so going down from the
+// highest coefficient lets z be either of the inputs
+func (z *UpperTriToeplitzOf[T]) setMulDirect(x, y *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	order := minOrder(x.order, y.order)
+	z.resize(order)
+	for i := order - 1; i >= 0; i-- {
+		z.set(i, convolve(x, y, i))
+	}
+
+	return z
+}
+
+// convolve returns the coefficient i of the product of x and y
+func convolve[T Field](x, y *UpperTriToeplitzOf[T], i int) T {
+	var product T
+	for k := i; k >= 0; k-- {
+		product += x.get(i-k) * y.get(k)
+	}
+
+	return product
+}
+
+func (m *UpperTriToeplitzOf[T]) AddE(inp *UpperTriToeplitzOf[T]) (*UpperTriToeplitzOf[T], error) {
+	if err := checkOrder(m.order, inp.order); err != nil {
+		return nil, err
+	}
+
+	return m.Add(inp), nil
+}
+
+func (m *UpperTriToeplitzOf[T]) SubE(inp *UpperTriToeplitzOf[T]) (*UpperTriToeplitzOf[T], error) {
+	if err := checkOrder(m.order, inp.order); err != nil {
+		return nil, err
+	}
+
+	return m.Sub(inp), nil
+}
+
+func (m *UpperTriToeplitzOf[T]) MulE(inp *UpperTriToeplitzOf[T]) (*UpperTriToeplitzOf[T], error) {
+	if err := checkOrder(m.order, inp.order); err != nil {
+		return nil, err
+	}
+
+	return m.Mul(inp), nil
 }
 
 /*
-shortcut borrowed from
-blog.jliszka.org/2013/10/24/exact-numeric-nth-derivatives.html
+the inverse of an upper triangular Toeplitz matrix is also upper
+triangular Toeplitz, and its first row is the reciprocal of the power
+series in the first row of the input. from a * b = 1, matching the
+coefficient of x^k on both sides gives a forward recurrence:
+
+b_0 = 1 / a_0
+b_k = -(1 / a_0) * Σ a_j*b_{k-j},   for j = 1..k
+
+this is O(n^2), instead of the O(n^3) needed to sum the Neumann series
+I + N + N^2 + ... N^n-1 of the nilpotent part (which the standard
+matrix still uses as a reference).
+*/
+func (m *UpperTriToeplitzOf[T]) Inv() *UpperTriToeplitzOf[T] {
+	return m.result().SetInv(m).detach()
+}
+
+func (z *UpperTriToeplitzOf[T]) SetInv(x *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	x = z.input(x, 0)
+	z.resize(x.order)
+	if x.order == 0 {
+		return z
+	}
+
+	a := x.get(0)
+	z.set(0, 1/a)
+	for k := 1; k < x.order; k++ {
+		var sum T
+		for j := 1; j <= k; j++ {
+			sum += x.get(j) * z.get(k-j)
+		}
+		z.set(k, -sum/a)
+	}
 
-a nilpotent matrix follows the form:
-(I - N)^-1 = Σ(I + N + N^2 + ... N^n-1)
+	return z
+}
 
-since the square upper triangular matrices we're using
-follow the form a*I + D, where a is some constant and D
-is a nilpotent matrix, we can use the above equation
-to simplify our inverse calculation.
+func (m *UpperTriToeplitzOf[T]) Div(inp *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	return m.result().SetDiv(m, inp).detach()
+}
 
-we calculate D by subtracting our input matrix by a*I.
+func (z *UpperTriToeplitzOf[T]) SetDiv(x, y *UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T] {
+	inv := z.temp(0, y.order).SetInv(y)
 
-in order to find (a*I + D)^-1, the formula is:
-(a*I + D)^-1 = (1 / a*I) * Σ(I + N + N^2 + ... N^n-1)
+	return z.SetMul(x, inv)
+}
+
+/*
+the inverse only exists when the leading coefficient is nonzero. a
+coefficient that is merely small is still a legitimate divisor (x seeded
+at 1e-15 has an inverse of 1e15), so only zero, or a subnormal value
+whose reciprocal overflows, counts as singular.
 */
-func (m *UpperTriToeplitz) Inv() *UpperTriToeplitz {
-	// derive a*I
-	a := m.get(0)
-	A := NewUpperTriToeplitz(m.order)
-	A.Fill(0, a)
+func (m *UpperTriToeplitzOf[T]) singular() bool {
+	return m.order == 0 || abs(m.get(0)) < smallestNormal[T]()
+}
 
-	// derive the nilpotent matrix D
-	D := m.Sub(A)
+/*
+L'Hôpital's rule needs to know which leading coefficients vanish, and
+since the coefficients have usually gone through some arithmetic already,
+that "zero" is relative to the largest coefficient of the matrix: a
+coefficient within singularTolerance of nothing is treated as zero too.
+*/
+const singularTolerance = 1e-14
 
-	// derive N from D and a
-	N := D
-	N.ElementDiv(-a)
+func (m *UpperTriToeplitzOf[T]) nearZero(i int) bool {
+	scale := 0.0
+	for k := 0; k < m.order; k++ {
+		scale = math.Max(scale, abs(m.get(k)))
+	}
 
-	// initialize the identity matrix
-	inv := NewUpperTriToeplitz(m.order)
-	inv.Fill(0, 1.0)
+	return abs(m.get(i)) <= singularTolerance*scale
+}
 
-	firstN := N.Copy()
-	for i := 0; i < m.order; i++ {
-		inv = inv.Add(N)
-		if i != m.order-1 {
-			N = firstN.Mul(N)
+func (m *UpperTriToeplitzOf[T]) InvE() (*UpperTriToeplitzOf[T], error) {
+	if m.singular() {
+		return nil, ErrDivisionByZero
+	}
+
+	return m.Inv(), nil
+}
+
+func (m *UpperTriToeplitzOf[T]) DivE(inp *UpperTriToeplitzOf[T]) (*UpperTriToeplitzOf[T], error) {
+	if err := checkOrder(m.order, inp.order); err != nil {
+		return nil, err
+	}
+
+	inv, err := inp.InvE()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Mul(inv), nil
+}
+
+/*
+DivLimit divides like DivE, but resolves 0/0 the way L'Hôpital's rule
+would. when the first k coefficients of the divisor are zero, and the
+numerator has at least as many leading zeros, both are shifted down by
+k coefficients (dividing out x^k) before dividing. the top k coefficients
+of the quotient depend on terms beyond the order, so the result is
+truncated to an order of order-k.
+*/
+func (m *UpperTriToeplitzOf[T]) DivLimit(inp *UpperTriToeplitzOf[T]) (*UpperTriToeplitzOf[T], error) {
+	if err := checkOrder(m.order, inp.order); err != nil {
+		return nil, err
+	}
+
+	shift := 0
+	for shift < inp.order && inp.nearZero(shift) {
+		shift++
+	}
+
+	if shift == inp.order {
+		return nil, ErrDivisionByZero
+	}
+
+	for i := 0; i < shift; i++ {
+		if !m.nearZero(i) {
+			return nil, ErrDivisionByZero
 		}
 	}
 
-	// divide by a to find the true inverse
-	inv.ElementDiv(a)
+	num := importUpperTriToeplitz(m.val[shift:])
+	den := importUpperTriToeplitz(inp.val[shift:])
 
-	return inv
+	return num.DivE(den)
 }
 
-func (m *UpperTriToeplitz) Div(inp *UpperTriToeplitz) *UpperTriToeplitz {
-	inv := inp.Inv()
-	out := m.Mul(inv)
+/*
+integer powers. with a nonzero leading coefficient, J.C.P. Miller's
+recurrence (see PowReal) gives the power in O(n^2), no matter how big
+the exponent is. without one, the recurrence doesn't apply, so we fall
+back to exponentiation by squaring, which takes O(log(n)) products.
+the recurrence also starts from a_0^n, and divides by a_0, so squaring
+is used too when a_0^n underflows, or when a_0 is tiny next to the other
+coefficients. negative exponents are taken on the inverse.
+*/
+func (m *UpperTriToeplitzOf[T]) Pow(n int) *UpperTriToeplitzOf[T] {
+	return m.result().SetPow(m, n).detach()
+}
 
-	return out
+func (z *UpperTriToeplitzOf[T]) SetPow(x *UpperTriToeplitzOf[T], n int) *UpperTriToeplitzOf[T] {
+	if n == 0 {
+		z.resize(x.order)
+		z.Reset(0)
+		z.Fill(0, 1.0)
+
+		return z
+	}
+
+	// both branches below read the base after writing to z, so it's always a copy.
+	// e is the magnitude of n, as a uint so that -math.MinInt doesn't overflow
+	base := z.temp(0, x.order)
+	e := uint(n)
+	if n < 0 {
+		base.SetInv(x)
+		e = -e
+	} else {
+		base.Set(x)
+	}
+
+	p := fromFloat[T](float64(e))
+	if base.millerPow(p) {
+		return z.setPowMiller(base, p)
+	}
+
+	return z.setPowSquaring(base, e)
 }
 
-func (m *UpperTriToeplitz) Pow(n int) *UpperTriToeplitz {
-	out := m.Copy()
-	for i := 0; i < n-1; i++ {
-		out = out.Mul(m)
+// whether the leading coefficient is big enough for Miller's recurrence
+func (m *UpperTriToeplitzOf[T]) millerPow(p T) bool {
+	a := m.get(0)
+	if a == 0 || m.nearZero(0) {
+		return false
 	}
 
-	return out
+	return abs(scalarPow(a, p)) >= smallestNormal[T]()
+}
+
+func (m *UpperTriToeplitzOf[T]) powSquaring(n uint) *UpperTriToeplitzOf[T] {
+	base := NewUpperTriToeplitzOf[T](m.order).Set(m)
+
+	return m.result().setPowSquaring(base, n)
+}
+
+// squares base in place, which can't be z
+func (z *UpperTriToeplitzOf[T]) setPowSquaring(base *UpperTriToeplitzOf[T], n uint) *UpperTriToeplitzOf[T] {
+	z.resize(base.order)
+	z.Reset(0)
+	z.Fill(0, 1.0)
+
+	for n > 0 {
+		if n&1 == 1 {
+			z.SetMul(z, base)
+		}
+
+		n >>= 1
+		if n > 0 {
+			base.SetMul(base, base)
+		}
+	}
+
+	return z
 }
 
 /* standard matrix for testing and benchmarking purposes */
@@ -251,7 +600,7 @@ func importMatrix(inp []float64) *Matrix {
 /* utility functions */
 
 func (m *Matrix) get(i, j int) float64 {
-	if i > m.order || j > m.order {
+	if i < 0 || j < 0 || i >= m.order || j >= m.order {
 		return 0.0
 	}
 
@@ -259,7 +608,7 @@ func (m *Matrix) get(i, j int) float64 {
 }
 
 func (m *Matrix) set(i, j int, val float64) {
-	if i > m.order || j > m.order {
+	if i < 0 || j < 0 || i >= m.order || j >= m.order {
 		return
 	}
 
@@ -427,8 +776,22 @@ func (m *Matrix) Div(inp *Matrix) *Matrix {
 }
 
 func (m *Matrix) Pow(n int) *Matrix {
+	if n == 0 {
+		out := NewMatrix(m.order)
+		out.Fill(0, 1.0)
+
+		return out
+	}
+
+	// the magnitude of n, as a uint so that -math.MinInt doesn't overflow
+	e := uint(n)
+	if n < 0 {
+		m = m.Inv()
+		e = -e
+	}
+
 	out := m.Copy()
-	for i := 0; i < n-1; i++ {
+	for i := uint(1); i < e; i++ {
 		out = out.Mul(m)
 	}
 
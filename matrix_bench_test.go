@@ -26,6 +26,8 @@ func randFloats(min, max float64, n int) []float64 {
 /* base functions */
 
 func benchmarkStandardAdd(order int, b *testing.B) {
+	b.ReportAllocs()
+
 	var mat *Matrix
 	input1 := randFloats(minBound, maxBound, order)
 	input2 := randFloats(minBound, maxBound, order)
@@ -40,6 +42,8 @@ func benchmarkStandardAdd(order int, b *testing.B) {
 }
 
 func benchmarkStandardSub(order int, b *testing.B) {
+	b.ReportAllocs()
+
 	var mat *Matrix
 	input1 := randFloats(minBound, maxBound, order)
 	input2 := randFloats(minBound, maxBound, order)
@@ -54,6 +58,8 @@ func benchmarkStandardSub(order int, b *testing.B) {
 }
 
 func benchmarkStandardMul(order int, b *testing.B) {
+	b.ReportAllocs()
+
 	var mat *Matrix
 	input1 := randFloats(minBound, maxBound, order)
 	input2 := randFloats(minBound, maxBound, order)
@@ -68,6 +74,8 @@ func benchmarkStandardMul(order int, b *testing.B) {
 }
 
 func benchmarkStandardDiv(order int, b *testing.B) {
+	b.ReportAllocs()
+
 	var mat *Matrix
 	input1 := randFloats(minBound, maxBound, order)
 	input2 := randFloats(minBound, maxBound, order)
@@ -82,6 +90,8 @@ func benchmarkStandardDiv(order int, b *testing.B) {
 }
 
 func benchmarkToeplitzAdd(order int, b *testing.B) {
+	b.ReportAllocs()
+
 	var mat *UpperTriToeplitz
 	input1 := randFloats(minBound, maxBound, order)
 	input2 := randFloats(minBound, maxBound, order)
@@ -96,6 +106,8 @@ func benchmarkToeplitzAdd(order int, b *testing.B) {
 }
 
 func benchmarkToeplitzSub(order int, b *testing.B) {
+	b.ReportAllocs()
+
 	var mat *UpperTriToeplitz
 	input1 := randFloats(minBound, maxBound, order)
 	input2 := randFloats(minBound, maxBound, order)
@@ -110,6 +122,8 @@ func benchmarkToeplitzSub(order int, b *testing.B) {
 }
 
 func benchmarkToeplitzMul(order int, b *testing.B) {
+	b.ReportAllocs()
+
 	var mat *UpperTriToeplitz
 	input1 := randFloats(minBound, maxBound, order)
 	input2 := randFloats(minBound, maxBound, order)
@@ -123,7 +137,25 @@ func benchmarkToeplitzMul(order int, b *testing.B) {
 	toeplitzMat = mat
 }
 
+func benchmarkToeplitzMulDirect(order int, b *testing.B) {
+	b.ReportAllocs()
+
+	var mat *UpperTriToeplitz
+	input1 := randFloats(minBound, maxBound, order)
+	input2 := randFloats(minBound, maxBound, order)
+
+	for i := 0; i < b.N; i++ {
+		inp1 := importUpperTriToeplitz(input1)
+		inp2 := importUpperTriToeplitz(input2)
+		mat = inp1.mulDirect(inp2)
+	}
+
+	toeplitzMat = mat
+}
+
 func benchmarkToeplitzDiv(order int, b *testing.B) {
+	b.ReportAllocs()
+
 	var mat *UpperTriToeplitz
 	input1 := randFloats(minBound, maxBound, order)
 	input2 := randFloats(minBound, maxBound, order)
@@ -137,6 +169,78 @@ func benchmarkToeplitzDiv(order int, b *testing.B) {
 	toeplitzMat = mat
 }
 
+func benchmarkToeplitzPow(order, n int, seed float64, b *testing.B) {
+	b.ReportAllocs()
+
+	var mat *UpperTriToeplitz
+	input := randFloats(-1, 1, order)
+	input[0] = seed
+
+	for i := 0; i < b.N; i++ {
+		inp := importUpperTriToeplitz(input)
+		mat = inp.Pow(n)
+	}
+
+	toeplitzMat = mat
+}
+
+func benchmarkToeplitzExp(order int, b *testing.B) {
+	b.ReportAllocs()
+
+	var mat *UpperTriToeplitz
+	input := randFloats(-1, 1, order)
+
+	for i := 0; i < b.N; i++ {
+		inp := importUpperTriToeplitz(input)
+		mat = inp.Exp()
+	}
+
+	toeplitzMat = mat
+}
+
+/* destination functions, which reuse the same matrix */
+
+func benchmarkToeplitzSetMul(order int, b *testing.B) {
+	b.ReportAllocs()
+
+	mat := new(UpperTriToeplitz)
+	inp1 := importUpperTriToeplitz(randFloats(minBound, maxBound, order))
+	inp2 := importUpperTriToeplitz(randFloats(minBound, maxBound, order))
+
+	for i := 0; i < b.N; i++ {
+		mat.SetMul(inp1, inp2)
+	}
+
+	toeplitzMat = mat
+}
+
+func benchmarkToeplitzSetDiv(order int, b *testing.B) {
+	b.ReportAllocs()
+
+	mat := new(UpperTriToeplitz)
+	inp1 := importUpperTriToeplitz(randFloats(minBound, maxBound, order))
+	inp2 := importUpperTriToeplitz(randFloats(minBound, maxBound, order))
+
+	for i := 0; i < b.N; i++ {
+		mat.SetDiv(inp1, inp2)
+	}
+
+	toeplitzMat = mat
+}
+
+func benchmarkToeplitzSetExp(order int, b *testing.B) {
+	b.ReportAllocs()
+
+	mat := new(UpperTriToeplitz)
+	inp := importUpperTriToeplitz(randFloats(-1, 1, order))
+
+	for i := 0; i < b.N; i++ {
+		mat.SetExp(inp)
+	}
+
+	toeplitzMat = mat
+}
+
 /* standard benchmarks */
 
 func BenchmarkTestStandardAdd10(b *testing.B) {
@@ -197,6 +301,22 @@ func BenchmarkTestToeplitzMul100(b *testing.B) {
 	benchmarkToeplitzMul(100, b)
 }
 
+func BenchmarkTestToeplitzMul1000(b *testing.B) {
+	benchmarkToeplitzMul(1000, b)
+}
+
+func BenchmarkTestToeplitzMul5000(b *testing.B) {
+	benchmarkToeplitzMul(5000, b)
+}
+
+func BenchmarkTestToeplitzMulDirect1000(b *testing.B) {
+	benchmarkToeplitzMulDirect(1000, b)
+}
+
+func BenchmarkTestToeplitzMulDirect5000(b *testing.B) {
+	benchmarkToeplitzMulDirect(5000, b)
+}
+
 func BenchmarkTestToeplitzDiv10(b *testing.B) {
 	benchmarkToeplitzDiv(10, b)
 }
@@ -204,3 +324,53 @@ func BenchmarkTestToeplitzDiv10(b *testing.B) {
 func BenchmarkTestToeplitzDiv100(b *testing.B) {
 	benchmarkToeplitzDiv(100, b)
 }
+
+func BenchmarkTestToeplitzPow10(b *testing.B) {
+	benchmarkToeplitzPow(100, 10, 1.5, b)
+}
+
+func BenchmarkTestToeplitzPow1000(b *testing.B) {
+	benchmarkToeplitzPow(100, 1000, 1.5, b)
+}
+
+func BenchmarkTestToeplitzPowZeroSeed10(b *testing.B) {
+	benchmarkToeplitzPow(100, 10, 0, b)
+}
+
+func BenchmarkTestToeplitzPowZeroSeed1000(b *testing.B) {
+	benchmarkToeplitzPow(100, 1000, 0, b)
+}
+
+func BenchmarkTestToeplitzExp10(b *testing.B) {
+	benchmarkToeplitzExp(10, b)
+}
+
+func BenchmarkTestToeplitzExp100(b *testing.B) {
+	benchmarkToeplitzExp(100, b)
+}
+
+/* destination benchmarks */
+
+func BenchmarkTestToeplitzSetMul10(b *testing.B) {
+	benchmarkToeplitzSetMul(10, b)
+}
+
+func BenchmarkTestToeplitzSetMul100(b *testing.B) {
+	benchmarkToeplitzSetMul(100, b)
+}
+
+func BenchmarkTestToeplitzSetDiv10(b *testing.B) {
+	benchmarkToeplitzSetDiv(10, b)
+}
+
+func BenchmarkTestToeplitzSetDiv100(b *testing.B) {
+	benchmarkToeplitzSetDiv(100, b)
+}
+
+func BenchmarkTestToeplitzSetExp10(b *testing.B) {
+	benchmarkToeplitzSetExp(10, b)
+}
+
+func BenchmarkTestToeplitzSetExp100(b *testing.B) {
+	benchmarkToeplitzSetExp(100, b)
+}
@@ -1,6 +1,8 @@
 package gdual
 
 import (
+	"errors"
+	"math"
 	"testing"
 )
 
@@ -515,3 +517,403 @@ func TestDiv(t *testing.T) {
 		}
 	}
 }
+
+func TestBounds(t *testing.T) {
+	mat := importUpperTriToeplitz([]float64{1, 2, 3})
+
+	// out of range writes are ignored and reads are zero
+	mat.Fill(3, 10.0)
+	mat.Fill(-1, 10.0)
+
+	for _, i := range []int{-1, 3, 4} {
+		if mat.get(i) != 0.0 {
+			t.Errorf("value mismatch on UTT index %d: have %f want %f",
+				i, mat.get(i), 0.0)
+		}
+	}
+
+	std := importMatrix([]float64{1, 2, 3})
+	std.Fill(3, 10.0)
+
+	for _, i := range []int{-1, 3, 4} {
+		if std.get(0, i) != 0.0 || std.get(i, 0) != 0.0 {
+			t.Errorf("value mismatch on standard index %d: have %f, %f want %f",
+				i, std.get(0, i), std.get(i, 0), 0.0)
+		}
+	}
+}
+
+func TestOrderMismatch(t *testing.T) {
+	type binaryOp func(a, b *UpperTriToeplitz) *UpperTriToeplitz
+	type checkedOp func(a, b *UpperTriToeplitz) (*UpperTriToeplitz, error)
+
+	tests := []struct {
+		name     string
+		op       binaryOp
+		checked  checkedOp
+		input1   []float64
+		input2   []float64
+		expected []float64
+	}{
+		{
+			name:     "add",
+			op:       (*UpperTriToeplitz).Add,
+			checked:  (*UpperTriToeplitz).AddE,
+			input1:   []float64{1, 2, 3, 4, 5},
+			input2:   []float64{2, 4},
+			expected: []float64{3, 6},
+		},
+		{
+			name:     "add",
+			op:       (*UpperTriToeplitz).Add,
+			checked:  (*UpperTriToeplitz).AddE,
+			input1:   []float64{2, 4},
+			input2:   []float64{1, 2, 3, 4, 5},
+			expected: []float64{3, 6},
+		},
+		{
+			name:     "sub",
+			op:       (*UpperTriToeplitz).Sub,
+			checked:  (*UpperTriToeplitz).SubE,
+			input1:   []float64{1, 2, 3, 4, 5},
+			input2:   []float64{2, 4, 6},
+			expected: []float64{-1, -2, -3},
+		},
+		{
+			name:     "sub",
+			op:       (*UpperTriToeplitz).Sub,
+			checked:  (*UpperTriToeplitz).SubE,
+			input1:   []float64{2, 4, 6},
+			input2:   []float64{1, 2, 3, 4, 5},
+			expected: []float64{1, 2, 3},
+		},
+		{
+			name:     "mul",
+			op:       (*UpperTriToeplitz).Mul,
+			checked:  (*UpperTriToeplitz).MulE,
+			input1:   []float64{1, 2, 3, 4, 5},
+			input2:   []float64{2, 4, 6},
+			expected: []float64{2, 8, 20},
+		},
+		{
+			name:     "mul",
+			op:       (*UpperTriToeplitz).Mul,
+			checked:  (*UpperTriToeplitz).MulE,
+			input1:   []float64{2, 4, 6},
+			input2:   []float64{1, 2, 3, 4, 5},
+			expected: []float64{2, 8, 20},
+		},
+		{
+			name:     "div",
+			op:       (*UpperTriToeplitz).Div,
+			checked:  (*UpperTriToeplitz).DivE,
+			input1:   []float64{1, 2, 3, 4, 5},
+			input2:   []float64{1, 1, 0},
+			expected: []float64{1, 1, 2},
+		},
+		{
+			name:     "div",
+			op:       (*UpperTriToeplitz).Div,
+			checked:  (*UpperTriToeplitz).DivE,
+			input1:   []float64{1, 2, 3},
+			input2:   []float64{1, 1, 0, 4, 5},
+			expected: []float64{1, 1, 2},
+		},
+	}
+
+	for i, tt := range tests {
+		inp1 := importUpperTriToeplitz(tt.input1)
+		inp2 := importUpperTriToeplitz(tt.input2)
+
+		mat := tt.op(inp1, inp2)
+		if mat.order != len(tt.expected) {
+			t.Errorf("order mismatch on %s test %d: have %d want %d",
+				tt.name, i, mat.order, len(tt.expected))
+		}
+
+		for n := 0; n < mat.order; n++ {
+			if mat.get(n) != tt.expected[n] {
+				t.Errorf("value mismatch on %s test %d (col %d): have %f want %f",
+					tt.name, i, n, mat.get(n), tt.expected[n])
+			}
+		}
+
+		if _, err := tt.checked(inp1, inp2); !errors.Is(err, ErrOrderMismatch) {
+			t.Errorf("expected order mismatch error on %s test %d: have %v",
+				tt.name, i, err)
+		}
+
+		if _, err := tt.checked(inp1, inp1.Copy()); err != nil {
+			t.Errorf("unexpected error on %s test %d: %v", tt.name, i, err)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	tests := []struct {
+		input    []float64
+		singular bool
+	}{
+		{input: []float64{2, 1, 3}, singular: false},
+		{input: []float64{-0.5, 0, 0}, singular: false},
+		{input: []float64{0, 1, 3}, singular: true},
+		{input: []float64{1e-20, 1, 3}, singular: false},
+		{input: []float64{1e-15, 1}, singular: false},
+		{input: []float64{1e-310, 1}, singular: true},
+		{input: []float64{0, 0, 0}, singular: true},
+		{input: []float64{}, singular: true},
+	}
+
+	for i, tt := range tests {
+		mat := importUpperTriToeplitz(tt.input)
+
+		inv, err := mat.InvE()
+		if tt.singular {
+			if !errors.Is(err, ErrDivisionByZero) {
+				t.Errorf("expected division by zero error on inverse test %d: have %v", i, err)
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error on inverse test %d: %v", i, err)
+		} else {
+			expected := mat.Inv()
+			for n := 0; n < mat.order; n++ {
+				if inv.get(n) != expected.get(n) {
+					t.Errorf("value mismatch on inverse test %d (col %d): have %f want %f",
+						i, n, inv.get(n), expected.get(n))
+				}
+			}
+		}
+
+		one := NewUpperTriToeplitz(mat.order)
+		one.Fill(0, 1.0)
+
+		_, err = one.DivE(mat)
+		if tt.singular && !errors.Is(err, ErrDivisionByZero) {
+			t.Errorf("expected division by zero error on div test %d: have %v", i, err)
+		}
+	}
+}
+
+func TestDivLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input1   []float64
+		input2   []float64
+		expected []float64
+		err      error
+	}{
+		{
+			// sin(x) / x at x = 0
+			name:     "sinc",
+			input1:   []float64{0, 1, 0, -1.0 / 6.0, 0, 1.0 / 120.0},
+			input2:   []float64{0, 1, 0, 0, 0, 0},
+			expected: []float64{1, 0, -1.0 / 6.0, 0, 1.0 / 120.0},
+		},
+		{
+			// x^2 / (x^2 + x^3) at x = 0
+			name:     "double root",
+			input1:   []float64{0, 0, 1, 0},
+			input2:   []float64{0, 0, 1, 1},
+			expected: []float64{1, -1},
+		},
+		{
+			name:     "regular",
+			input1:   []float64{2, 4},
+			input2:   []float64{1, 5},
+			expected: []float64{2.0, -6.0},
+		},
+		{
+			// 1 / x has a pole at x = 0
+			name:   "pole",
+			input1: []float64{1, 1, 0},
+			input2: []float64{0, 1, 0},
+			err:    ErrDivisionByZero,
+		},
+		{
+			name:   "zero",
+			input1: []float64{0, 1, 0},
+			input2: []float64{0, 0, 0},
+			err:    ErrDivisionByZero,
+		},
+		{
+			name:   "mismatch",
+			input1: []float64{0, 1, 0},
+			input2: []float64{0, 1},
+			err:    ErrOrderMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		inp1 := importUpperTriToeplitz(tt.input1)
+		inp2 := importUpperTriToeplitz(tt.input2)
+
+		mat, err := inp1.DivLimit(inp2)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error mismatch on %s: have %v want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error on %s: %v", tt.name, err)
+			continue
+		}
+
+		if mat.order != len(tt.expected) {
+			t.Errorf("order mismatch on %s: have %d want %d",
+				tt.name, mat.order, len(tt.expected))
+		}
+
+		for n := 0; n < mat.order; n++ {
+			if mat.get(n) != tt.expected[n] {
+				t.Errorf("value mismatch on %s (col %d): have %f want %f",
+					tt.name, n, mat.get(n), tt.expected[n])
+			}
+		}
+	}
+}
+
+func TestInvReference(t *testing.T) {
+	// the recurrence must agree with the dense Neumann series
+	for order := 1; order <= 24; order++ {
+		input := randFloats(-1, 1, order)
+		input[0] += 2.0
+
+		std := importMatrix(input).Inv()
+		mat := importUpperTriToeplitz(input).Inv()
+
+		for n := 0; n < order; n++ {
+			if !almostEqual(mat.get(n), std.get(0, n)) {
+				t.Errorf("value mismatch on order %d (col %d): have %g want %g",
+					order, n, mat.get(n), std.get(0, n))
+			}
+		}
+
+		// and the result must actually be an inverse
+		one := importUpperTriToeplitz(input).Mul(mat)
+		for n := 0; n < order; n++ {
+			expected := 0.0
+			if n == 0 {
+				expected = 1.0
+			}
+
+			if !almostEqual(one.get(n), expected) {
+				t.Errorf("identity mismatch on order %d (col %d): have %g want %g",
+					order, n, one.get(n), expected)
+			}
+		}
+	}
+}
+
+func TestPow(t *testing.T) {
+	tests := []struct {
+		n        int
+		input    []float64
+		expected []float64
+	}{
+		{
+			n:        0,
+			input:    []float64{2, 3, 4},
+			expected: []float64{1, 0, 0},
+		},
+		{
+			n:        1,
+			input:    []float64{2, 3, 4},
+			expected: []float64{2, 3, 4},
+		},
+		{
+			n:        3,
+			input:    []float64{1, 2, 3, 4},
+			expected: []float64{1, 6, 21, 56},
+		},
+		{
+			n:        -1,
+			input:    []float64{2, 1, 0},
+			expected: []float64{0.5, -0.25, 0.125},
+		},
+		{
+			n:        -2,
+			input:    []float64{1, 1, 0, 0},
+			expected: []float64{1, -2, 3, -4},
+		},
+		{
+			n:        2,
+			input:    []float64{0, 1, 2, 3},
+			expected: []float64{0, 0, 1, 4},
+		},
+		{
+			n:        5,
+			input:    []float64{0, 1, 2, 3},
+			expected: []float64{0, 0, 0, 0},
+		},
+	}
+
+	for i, tt := range tests {
+		mat := importUpperTriToeplitz(tt.input).Pow(tt.n)
+		if mat.order != len(tt.expected) {
+			t.Errorf("order mismatch on UTT test %d: have %d want %d",
+				i, mat.order, len(tt.expected))
+		}
+
+		for n := 0; n < mat.order; n++ {
+			if mat.get(n) != tt.expected[n] {
+				t.Errorf("value mismatch on UTT test %d (col %d): have %f want %f",
+					i, n, mat.get(n), tt.expected[n])
+			}
+		}
+	}
+}
+
+func TestPowTinySeed(t *testing.T) {
+	// a_0^n underflows, but the coefficients above it don't
+	tests := []struct {
+		seed     float64
+		n        int
+		expected []float64
+	}{
+		{1e-200, 3, []float64{0, 0, 3e-200, 1, 0, 0}},
+		{1e-120, 5, []float64{0, 0, 0, 1e-239, 5e-120, 1}},
+		{1e-15, 2, []float64{1e-30, 2e-15, 1, 0}},
+	}
+
+	for i, tt := range tests {
+		mat := NewGDual(len(tt.expected), tt.seed, true).Pow(tt.n)
+		for k, want := range tt.expected {
+			if have := mat.mat.get(k); math.Abs(have-want) > 1e-12*math.Abs(want) {
+				t.Errorf("value mismatch on tiny seed test %d (col %d): have %g want %g", i, k, have, want)
+			}
+		}
+	}
+}
+
+func TestPowLargeExponent(t *testing.T) {
+	order := 12
+	input := randFloats(-0.1, 0.1, order)
+	input[0] = 1.001
+
+	// the recurrence and repeated squaring must agree
+	for _, n := range []int{2, 7, 64, 1000} {
+		mat := importUpperTriToeplitz(input)
+
+		have := mat.Pow(n)
+		want := mat.powSquaring(uint(n))
+
+		for k := 0; k < order; k++ {
+			if math.Abs(have.get(k)-want.get(k)) > 1e-9*math.Max(1.0, math.Abs(want.get(k))) {
+				t.Errorf("value mismatch on exponent %d (col %d): have %g want %g",
+					n, k, have.get(k), want.get(k))
+			}
+		}
+	}
+
+	// -math.MinInt doesn't fit an int, and 2^(-2^63) underflows to zero
+	input[0] = 2.0
+	have := importUpperTriToeplitz(input).Pow(math.MinInt)
+	for k := 0; k < order; k++ {
+		if have.get(k) != 0 {
+			t.Errorf("value mismatch on exponent %d (col %d): have %g want %g",
+				math.MinInt, k, have.get(k), 0.0)
+		}
+	}
+}
@@ -0,0 +1,467 @@
+/*
+
+multivariate generalized dual numbers, following the truncated
+polynomial algebra from Audi.
+
+a multivariate dual number is a polynomial in the displacements
+dx, dy, ... of every variable it depends on, truncated so that only
+monomials with a total degree below the order are kept. the coefficient
+of dx^i * dy^j is the mixed partial ∂^(i+j)f / ∂x^i ∂y^j divided by
+i! * j!, so a single evaluation yields every mixed partial up to
+the order.
+
+each variable is identified by a symbol. operations on two numbers
+with different symbols first extend both to the union of their symbols,
+so variables can be freely mixed.
+
+elementary functions are computed by splitting the polynomial into
+its value a_0 and nilpotent part h, then composing the univariate
+Taylor series of the function at a_0 (from UpperTriToeplitz) with h:
+
+f(a_0 + h) = Σ f^(k)(a_0)/k! * h^k
+
+the sum is finite, since h^k is zero once k reaches the order.
+
+*/
+
+package gdual
+
+import (
+	"math"
+	"sort"
+)
+
+/*
+a monomial, encoded as the exponent of every symbol in order. each
+exponent is a varint (7 bits a byte, with the top bit set on every byte
+but the last), so an exponent of any size fits, and small ones take a
+single byte. the encoding is unique, so monomials can be map keys.
+*/
+type monomial string
+
+func appendExponent(buf []byte, e int) []byte {
+	for e >= 0x80 {
+		buf = append(buf, byte(e)|0x80)
+		e >>= 7
+	}
+
+	return append(buf, byte(e))
+}
+
+// the exponent starting at byte i of m, and the byte after it
+func (m monomial) exponent(i int) (int, int) {
+	e, shift := 0, 0
+	for ; m[i] >= 0x80; i++ {
+		e |= int(m[i]&0x7f) << shift
+		shift += 7
+	}
+
+	return e | int(m[i])<<shift, i + 1
+}
+
+func newMonomial(exponents []int) monomial {
+	buf := make([]byte, 0, len(exponents))
+	for _, e := range exponents {
+		buf = appendExponent(buf, e)
+	}
+
+	return monomial(buf)
+}
+
+func (m monomial) exponents() []int {
+	var exponents []int
+	for i := 0; i < len(m); {
+		var e int
+		e, i = m.exponent(i)
+		exponents = append(exponents, e)
+	}
+
+	return exponents
+}
+
+func (m monomial) degree() int {
+	degree := 0
+	for i := 0; i < len(m); {
+		var e int
+		e, i = m.exponent(i)
+		degree += e
+	}
+
+	return degree
+}
+
+func (m monomial) mul(inp monomial) monomial {
+	buf := make([]byte, 0, len(m))
+	for i, j := 0, 0; i < len(m); {
+		var a, b int
+		a, i = m.exponent(i)
+		b, j = inp.exponent(j)
+		buf = appendExponent(buf, a+b)
+	}
+
+	return monomial(buf)
+}
+
+type MultiGDual struct {
+	order   int
+	symbols []string
+	terms   map[monomial]float64
+}
+
+func newMultiGDual(order int, symbols []string) *MultiGDual {
+	gdual := &MultiGDual{
+		order:   order,
+		symbols: symbols,
+		terms:   make(map[monomial]float64),
+	}
+
+	return gdual
+}
+
+// NewMultiGDual creates the variable named by symbol, seeded at seed
+func NewMultiGDual(order int, symbol string, seed float64) *MultiGDual {
+	gdual := newMultiGDual(order, []string{symbol})
+	gdual.set(newMonomial([]int{0}), seed)
+	gdual.set(newMonomial([]int{1}), 1.0)
+
+	return gdual
+}
+
+// NewMultiConstant creates a constant, which depends on no symbols
+func NewMultiConstant(order int, val float64) *MultiGDual {
+	gdual := newMultiGDual(order, nil)
+	gdual.set(newMonomial(nil), val)
+
+	return gdual
+}
+
+/* utility functions */
+
+func (g *MultiGDual) get(m monomial) float64 {
+	return g.terms[m]
+}
+
+func (g *MultiGDual) set(m monomial, val float64) {
+	if m.degree() >= g.order {
+		return
+	}
+
+	if val == 0 {
+		delete(g.terms, m)
+		return
+	}
+
+	g.terms[m] = val
+}
+
+func (g *MultiGDual) Copy() *MultiGDual {
+	symbols := make([]string, len(g.symbols))
+	copy(symbols, g.symbols)
+
+	out := newMultiGDual(g.order, symbols)
+	for m, val := range g.terms {
+		out.terms[m] = val
+	}
+
+	return out
+}
+
+func (g *MultiGDual) Order() int {
+	return g.order
+}
+
+func (g *MultiGDual) Symbols() []string {
+	symbols := make([]string, len(g.symbols))
+	copy(symbols, g.symbols)
+
+	return symbols
+}
+
+// extend rewrites the terms in terms of a superset of the current symbols
+func (g *MultiGDual) extend(symbols []string) *MultiGDual {
+	index := make(map[string]int, len(symbols))
+	for i, s := range symbols {
+		index[s] = i
+	}
+
+	out := newMultiGDual(g.order, symbols)
+	for m, val := range g.terms {
+		exponents := make([]int, len(symbols))
+		for i, e := range m.exponents() {
+			exponents[index[g.symbols[i]]] = e
+		}
+		out.terms[newMonomial(exponents)] = val
+	}
+
+	return out
+}
+
+// unionSymbols returns the sorted union of two lists of symbols
+func unionSymbols(a, b []string) []string {
+	seen := make(map[string]bool)
+	symbols := make([]string, 0, len(a)+len(b))
+	for _, s := range append(append([]string{}, a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+// align extends both numbers to the union of their symbols and the lower order
+func (g *MultiGDual) align(inp *MultiGDual) (*MultiGDual, *MultiGDual) {
+	symbols := unionSymbols(g.symbols, inp.symbols)
+
+	a := g.extend(symbols)
+	b := inp.extend(symbols)
+
+	order := a.order
+	if b.order < order {
+		order = b.order
+	}
+	a.truncate(order)
+	b.truncate(order)
+
+	return a, b
+}
+
+func (g *MultiGDual) truncate(order int) {
+	g.order = order
+	for m := range g.terms {
+		if m.degree() >= order {
+			delete(g.terms, m)
+		}
+	}
+}
+
+// lookup the exponent vector for a set of named partials. a negative
+// exponent, or one that doesn't fit below the order, has no monomial.
+func (g *MultiGDual) monomial(partials map[string]int) (monomial, bool) {
+	exponents := make([]int, len(g.symbols))
+	for s, e := range partials {
+		if e < 0 || e >= g.order {
+			return "", false
+		}
+
+		if e == 0 {
+			continue
+		}
+
+		i := sort.SearchStrings(g.symbols, s)
+		if i == len(g.symbols) || g.symbols[i] != s {
+			return "", false
+		}
+		exponents[i] = e
+	}
+
+	return newMonomial(exponents), true
+}
+
+/* accessors */
+
+func (g *MultiGDual) Value() float64 {
+	return g.get(newMonomial(make([]int, len(g.symbols))))
+}
+
+// Coefficient returns the Taylor coefficient of the monomial given by
+// the exponent of each symbol, where missing symbols have exponent 0.
+// monomials with a total degree at or above the order are truncated, and
+// negative exponents don't exist, so both give 0.
+func (g *MultiGDual) Coefficient(partials map[string]int) float64 {
+	m, ok := g.monomial(partials)
+	if !ok {
+		return 0.0
+	}
+
+	return g.get(m)
+}
+
+// Derivative returns the mixed partial derivative given by the number
+// of times to differentiate with respect to each symbol
+func (g *MultiGDual) Derivative(partials map[string]int) float64 {
+	coef := g.Coefficient(partials)
+	for _, e := range partials {
+		for i := 2; i <= e; i++ {
+			coef *= float64(i)
+		}
+	}
+
+	return coef
+}
+
+/* arithmetic */
+
+func (g *MultiGDual) Add(inp *MultiGDual) *MultiGDual {
+	a, b := g.align(inp)
+	for m, val := range b.terms {
+		a.set(m, a.get(m)+val)
+	}
+
+	return a
+}
+
+func (g *MultiGDual) Sub(inp *MultiGDual) *MultiGDual {
+	a, b := g.align(inp)
+	for m, val := range b.terms {
+		a.set(m, a.get(m)-val)
+	}
+
+	return a
+}
+
+func (g *MultiGDual) Mul(inp *MultiGDual) *MultiGDual {
+	a, b := g.align(inp)
+
+	out := newMultiGDual(a.order, a.symbols)
+	for ma, va := range a.terms {
+		da := ma.degree()
+		for mb, vb := range b.terms {
+			if da+mb.degree() >= out.order {
+				continue
+			}
+
+			m := ma.mul(mb)
+			out.set(m, out.get(m)+va*vb)
+		}
+	}
+
+	return out
+}
+
+func (g *MultiGDual) Div(inp *MultiGDual) *MultiGDual {
+	return g.Mul(inp.Inv())
+}
+
+func (g *MultiGDual) Inv() *MultiGDual {
+	return g.compose(func(m *UpperTriToeplitz) *UpperTriToeplitz {
+		return m.PowReal(-1)
+	})
+}
+
+func (g *MultiGDual) Pow(n int) *MultiGDual {
+	return g.PowReal(float64(n))
+}
+
+func (g *MultiGDual) PowReal(p float64) *MultiGDual {
+	return g.compose(func(m *UpperTriToeplitz) *UpperTriToeplitz {
+		return m.PowReal(p)
+	})
+}
+
+// a^e = exp(e * log(a))
+func (g *MultiGDual) PowGDual(e *MultiGDual) *MultiGDual {
+	return g.Log().Mul(e).Exp()
+}
+
+/* elementary functions */
+
+// compose applies the univariate function fn to the polynomial
+func (g *MultiGDual) compose(fn func(*UpperTriToeplitz) *UpperTriToeplitz) *MultiGDual {
+	a := g.Value()
+
+	// the Taylor coefficients of fn at a
+	seed := NewUpperTriToeplitz(g.order)
+	seed.Fill(0, a)
+	if g.order > 1 {
+		seed.Fill(1, 1.0)
+	}
+	coef := fn(seed)
+
+	// the nilpotent part of g
+	h := g.Copy()
+	h.set(newMonomial(make([]int, len(h.symbols))), 0.0)
+
+	// horner's method, Σ c_k * h^k = c_0 + h*(c_1 + h*(c_2 + ...))
+	zero := newMonomial(make([]int, len(h.symbols)))
+	out := newMultiGDual(g.order, h.symbols)
+	for k := g.order - 1; k >= 0; k-- {
+		out = out.Mul(h)
+		out.set(zero, out.get(zero)+coef.get(k))
+	}
+
+	return out
+}
+
+func (g *MultiGDual) Exp() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Exp)
+}
+
+func (g *MultiGDual) Log() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Log)
+}
+
+func (g *MultiGDual) Sqrt() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Sqrt)
+}
+
+func (g *MultiGDual) Sin() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Sin)
+}
+
+func (g *MultiGDual) Cos() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Cos)
+}
+
+func (g *MultiGDual) Tan() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Tan)
+}
+
+func (g *MultiGDual) Sinh() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Sinh)
+}
+
+func (g *MultiGDual) Cosh() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Cosh)
+}
+
+func (g *MultiGDual) Tanh() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Tanh)
+}
+
+func (g *MultiGDual) Asin() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Asin)
+}
+
+func (g *MultiGDual) Acos() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Acos)
+}
+
+func (g *MultiGDual) Atan() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Atan)
+}
+
+// Atan2 returns atan(g / inp), using the signs of both to pick the quadrant
+func (g *MultiGDual) Atan2(inp *MultiGDual) *MultiGDual {
+	y, x := g.align(inp)
+	zero := newMonomial(make([]int, len(y.symbols)))
+
+	// atan2 only differs from atan(y/x) or -atan(x/y) by a constant,
+	// so use whichever quotient is better conditioned at the seed
+	var out *MultiGDual
+	if math.Abs(x.Value()) >= math.Abs(y.Value()) {
+		out = y.Div(x).Atan()
+	} else {
+		out = x.Div(y).Atan()
+		for m, val := range out.terms {
+			out.terms[m] = -val
+		}
+	}
+	out.set(zero, math.Atan2(y.Value(), x.Value()))
+
+	return out
+}
+
+func (g *MultiGDual) Asinh() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Asinh)
+}
+
+func (g *MultiGDual) Acosh() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Acosh)
+}
+
+func (g *MultiGDual) Atanh() *MultiGDual {
+	return g.compose((*UpperTriToeplitz).Atanh)
+}
@@ -0,0 +1,228 @@
+package gdual
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMultiPartials(t *testing.T) {
+	order := 4
+	x0, y0 := 1.0, 2.0
+
+	x := NewMultiGDual(order, "x", x0)
+	y := NewMultiGDual(order, "y", y0)
+
+	// f(x, y) = x*y^2 + sin(x)*exp(y)
+	f := x.Mul(y.Pow(2)).Add(x.Sin().Mul(y.Exp()))
+
+	sin, cos, exp := math.Sin(x0), math.Cos(x0), math.Exp(y0)
+
+	tests := []struct {
+		partials map[string]int
+		expected float64
+	}{
+		{map[string]int{}, x0*y0*y0 + sin*exp},
+		{map[string]int{"x": 1}, y0*y0 + cos*exp},
+		{map[string]int{"y": 1}, 2*x0*y0 + sin*exp},
+		{map[string]int{"x": 2}, -sin * exp},
+		{map[string]int{"x": 1, "y": 1}, 2*y0 + cos*exp},
+		{map[string]int{"y": 2}, 2*x0 + sin*exp},
+		{map[string]int{"x": 2, "y": 1}, -sin * exp},
+		{map[string]int{"x": 1, "y": 2}, 2 + cos*exp},
+		{map[string]int{"x": 3}, -cos * exp},
+	}
+
+	for i, tt := range tests {
+		have := f.Derivative(tt.partials)
+		if !almostEqual(have, tt.expected) {
+			t.Errorf("value mismatch on partial test %d (%v): have %g want %g",
+				i, tt.partials, have, tt.expected)
+		}
+	}
+
+	// a total degree equal to the order is truncated
+	if have := f.Derivative(map[string]int{"x": 2, "y": 2}); have != 0 {
+		t.Errorf("expected truncated partial: have %g want 0", have)
+	}
+}
+
+func TestMultiDiv(t *testing.T) {
+	order := 4
+	x0, y0 := 3.0, 2.0
+
+	x := NewMultiGDual(order, "x", x0)
+	y := NewMultiGDual(order, "y", y0)
+	two := NewMultiConstant(order, 2.0)
+
+	// f(x, y) = 2x / y
+	f := two.Mul(x).Div(y)
+
+	tests := []struct {
+		partials map[string]int
+		expected float64
+	}{
+		{map[string]int{}, 2 * x0 / y0},
+		{map[string]int{"x": 1}, 2 / y0},
+		{map[string]int{"y": 1}, -2 * x0 / (y0 * y0)},
+		{map[string]int{"x": 1, "y": 1}, -2 / (y0 * y0)},
+		{map[string]int{"y": 2}, 4 * x0 / (y0 * y0 * y0)},
+		{map[string]int{"y": 3}, -12 * x0 / (y0 * y0 * y0 * y0)},
+		{map[string]int{"x": 2}, 0},
+	}
+
+	for i, tt := range tests {
+		have := f.Derivative(tt.partials)
+		if !almostEqual(have, tt.expected) {
+			t.Errorf("value mismatch on div test %d (%v): have %g want %g",
+				i, tt.partials, have, tt.expected)
+		}
+	}
+}
+
+func TestMultiUnivariate(t *testing.T) {
+	order := 6
+	inp := 0.4
+
+	// with a single symbol, every function matches the univariate result
+	x := NewMultiGDual(order, "x", inp)
+	u := NewGDual(order, inp, true)
+
+	tests := []struct {
+		name     string
+		have     *MultiGDual
+		expected *GDual
+	}{
+		{"exp", x.Exp(), u.Exp()},
+		{"log", x.Log(), u.Log()},
+		{"sqrt", x.Sqrt(), u.Sqrt()},
+		{"tan", x.Tan(), u.Tan()},
+		{"tanh", x.Tanh(), u.Tanh()},
+		{"asin", x.Asin(), u.Asin()},
+		{"acos", x.Acos(), u.Acos()},
+		{"atanh", x.Atanh(), u.Atanh()},
+		{"pow", x.PowReal(-2.5), u.PowReal(-2.5)},
+		{"pow x^x", x.PowGDual(x), u.PowGDual(u)},
+	}
+
+	for _, tt := range tests {
+		for k := 0; k < order; k++ {
+			have := tt.have.Coefficient(map[string]int{"x": k})
+			want := tt.expected.mat.get(k)
+			if !almostEqual(have, want) {
+				t.Errorf("value mismatch on %s (col %d): have %g want %g",
+					tt.name, k, have, want)
+			}
+		}
+	}
+}
+
+func TestMultiAtan2(t *testing.T) {
+	order := 4
+
+	tests := []struct {
+		y float64
+		x float64
+	}{
+		{y: 0.5, x: 2.0},
+		{y: 3.0, x: -0.5},
+		{y: -2.0, x: -1.0},
+	}
+
+	for i, tt := range tests {
+		y := NewMultiGDual(order, "y", tt.y)
+		x := NewMultiGDual(order, "x", tt.x)
+		f := y.Atan2(x)
+
+		r2 := tt.x*tt.x + tt.y*tt.y
+		expected := []struct {
+			partials map[string]int
+			val      float64
+		}{
+			{map[string]int{}, math.Atan2(tt.y, tt.x)},
+			{map[string]int{"x": 1}, -tt.y / r2},
+			{map[string]int{"y": 1}, tt.x / r2},
+			{map[string]int{"x": 1, "y": 1}, (tt.y*tt.y - tt.x*tt.x) / (r2 * r2)},
+		}
+
+		for _, e := range expected {
+			have := f.Derivative(e.partials)
+			if !almostEqual(have, e.val) {
+				t.Errorf("value mismatch on atan2 test %d (%v): have %g want %g",
+					i, e.partials, have, e.val)
+			}
+		}
+	}
+}
+
+func TestMultiSymbols(t *testing.T) {
+	order := 3
+
+	x := NewMultiGDual(order, "x", 1.0)
+	z := NewMultiGDual(order, "z", 1.0)
+	y := NewMultiGDual(order, "y", 1.0)
+	c := NewMultiConstant(order, 5.0)
+
+	f := z.Mul(x).Add(y).Sub(c)
+
+	symbols := f.Symbols()
+	expected := []string{"x", "y", "z"}
+	if len(symbols) != len(expected) {
+		t.Fatalf("symbol mismatch: have %v want %v", symbols, expected)
+	}
+
+	for i := range expected {
+		if symbols[i] != expected[i] {
+			t.Errorf("symbol mismatch (index %d): have %s want %s",
+				i, symbols[i], expected[i])
+		}
+	}
+
+	if f.Value() != -3.0 {
+		t.Errorf("value mismatch: have %g want %g", f.Value(), -3.0)
+	}
+
+	if have := f.Derivative(map[string]int{"w": 1}); have != 0 {
+		t.Errorf("expected zero partial for unknown symbol: have %g", have)
+	}
+}
+
+func TestMultiLimits(t *testing.T) {
+	order := 4
+
+	x := NewMultiGDual(order, "x", 2.0)
+	f := x.Exp()
+
+	// exponents that used to wrap around to the value
+	for _, e := range []int{-1, -256, order, 256, 257} {
+		if have := f.Derivative(map[string]int{"x": e}); have != 0 {
+			t.Errorf("expected no partial for exponent %d: have %g", e, have)
+		}
+	}
+
+	// orders past a byte: f(x, y) = x * y^270 at (1, 1), where the
+	// coefficient of dx dy^k is C(270, k)
+	high := 300
+	binomial := func(k int) float64 {
+		c := 1.0
+		for j := 0; j < k; j++ {
+			c = c * float64(270-j) / float64(j+1)
+		}
+		return c
+	}
+
+	eager := NewMultiGDual(high, "x", 1.0).Mul(NewMultiGDual(high, "y", 1.0).Pow(270))
+	lazy, err := NewVariable("x").Mul(NewVariable("y").Pow(270)).EvalMulti(high, map[string]float64{"x": 1.0, "y": 1.0})
+	if err != nil {
+		t.Fatalf("failed on high order eval: %v", err)
+	}
+
+	for _, k := range []int{0, 14, 255, 256, 270} {
+		expected := binomial(k)
+		for _, g := range []*MultiGDual{eager, lazy} {
+			have := g.Coefficient(map[string]int{"x": 1, "y": k})
+			if math.Abs(have-expected) > 1e-9*expected {
+				t.Errorf("value mismatch on high order test (y^%d): have %g want %g", k, have, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+/*
+
+exact rational generalized dual numbers.
+
+RatGDual is GDual with big.Rat coefficients. a rational function of a
+rational seed has rational coefficients at every order, so Add, Sub,
+Mul, Div, Inv and Pow never round, and the derivatives come out exact
+no matter how high the order is.
+
+the elementary functions only stay rational at a few seeds (see
+ratmatrix.go), and return ErrNotRational everywhere else. like
+BigGDual, the first error is carried through the rest of the
+expression, so it only has to be checked once with Err.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type RatGDual struct {
+	mat      *RatUpperTriToeplitz
+	variable bool
+	err      error
+}
+
+func NewRatGDual(order int, seed *big.Rat, variable bool) *RatGDual {
+	mat := NewRatUpperTriToeplitz(order)
+	mat.Fill(0, seed)
+	if variable {
+		mat.Fill(1, big.NewRat(1, 1))
+	}
+
+	gdual := &RatGDual{
+		mat:      mat,
+		variable: variable,
+	}
+
+	return gdual
+}
+
+func importRatGDual(mat *RatUpperTriToeplitz, variable bool, err error) *RatGDual {
+	if err != nil {
+		mat = NewRatUpperTriToeplitz(mat.order)
+	}
+
+	gdual := &RatGDual{
+		mat:      mat,
+		variable: variable,
+		err:      err,
+	}
+
+	return gdual
+}
+
+// applies a function of the matrix, unless g already carries an error
+func (g *RatGDual) apply(fn func(*RatUpperTriToeplitz) (*RatUpperTriToeplitz, error)) *RatGDual {
+	if g.err != nil {
+		return g
+	}
+
+	mat, err := fn(g.mat)
+	if err != nil {
+		return importRatGDual(g.mat, g.variable, err)
+	}
+
+	return importRatGDual(mat, g.variable, nil)
+}
+
+// applies a function of both matrices, keeping the first error of either
+func (g *RatGDual) combine(inp *RatGDual, fn func(a, b *RatUpperTriToeplitz) (*RatUpperTriToeplitz, error)) *RatGDual {
+	variable := g.variable || inp.variable
+	if g.err != nil {
+		return importRatGDual(g.mat, variable, g.err)
+	}
+
+	if inp.err != nil {
+		return importRatGDual(g.mat, variable, inp.err)
+	}
+
+	mat, err := fn(g.mat, inp.mat)
+	if err != nil {
+		return importRatGDual(g.mat, variable, err)
+	}
+
+	return importRatGDual(mat, variable, nil)
+}
+
+/* accessors */
+
+// Err returns the first error in the computation of g, if any
+func (g *RatGDual) Err() error {
+	return g.err
+}
+
+func (g *RatGDual) Order() int {
+	return g.mat.order
+}
+
+// Value returns f(x0), the value of the function at the seed
+func (g *RatGDual) Value() *big.Rat {
+	return g.mat.Coefficient(0)
+}
+
+// Coefficient returns the k-th Taylor coefficient, f^(k)(x0) / k!
+func (g *RatGDual) Coefficient(k int) (*big.Rat, error) {
+	if k < 0 || k >= g.mat.order {
+		return nil, fmt.Errorf("%w: coefficient %d of order %d", ErrIndexOutOfRange, k, g.mat.order)
+	}
+
+	return g.mat.Coefficient(k), nil
+}
+
+// Coefficients returns a copy of every Taylor coefficient
+func (g *RatGDual) Coefficients() []*big.Rat {
+	coefs := make([]*big.Rat, g.mat.order)
+	for k := range coefs {
+		coefs[k] = g.mat.Coefficient(k)
+	}
+
+	return coefs
+}
+
+// Derivative returns the k-th derivative, f^(k)(x0)
+func (g *RatGDual) Derivative(k int) (*big.Rat, error) {
+	coef, err := g.Coefficient(k)
+	if err != nil {
+		return nil, err
+	}
+
+	factorial := new(big.Int).MulRange(1, int64(k))
+	coef.Mul(coef, new(big.Rat).SetInt(factorial))
+
+	return coef, nil
+}
+
+// Derivatives returns every derivative, from f(x0) up to f^(order-1)(x0)
+func (g *RatGDual) Derivatives() []*big.Rat {
+	derivs := g.Coefficients()
+
+	factorial := big.NewInt(1)
+	scale := new(big.Rat)
+	for k := range derivs {
+		if k > 1 {
+			factorial.Mul(factorial, big.NewInt(int64(k)))
+		}
+		derivs[k].Mul(derivs[k], scale.SetInt(factorial))
+	}
+
+	return derivs
+}
+
+/* arithmetic */
+
+func (g *RatGDual) Add(inp *RatGDual) *RatGDual {
+	return g.combine(inp, func(a, b *RatUpperTriToeplitz) (*RatUpperTriToeplitz, error) {
+		return a.Add(b), nil
+	})
+}
+
+func (g *RatGDual) Sub(inp *RatGDual) *RatGDual {
+	return g.combine(inp, func(a, b *RatUpperTriToeplitz) (*RatUpperTriToeplitz, error) {
+		return a.Sub(b), nil
+	})
+}
+
+func (g *RatGDual) Mul(inp *RatGDual) *RatGDual {
+	return g.combine(inp, func(a, b *RatUpperTriToeplitz) (*RatUpperTriToeplitz, error) {
+		return a.Mul(b), nil
+	})
+}
+
+func (g *RatGDual) Div(inp *RatGDual) *RatGDual {
+	return g.combine(inp, (*RatUpperTriToeplitz).Div)
+}
+
+// DivLimit divides like Div, but resolves 0/0 with L'Hôpital's rule,
+// losing one order per common root (see DivLimit in matrix.go)
+func (g *RatGDual) DivLimit(inp *RatGDual) *RatGDual {
+	return g.combine(inp, (*RatUpperTriToeplitz).DivLimit)
+}
+
+func (g *RatGDual) Inv() *RatGDual {
+	return g.apply((*RatUpperTriToeplitz).Inv)
+}
+
+func (g *RatGDual) Pow(n int) *RatGDual {
+	return g.apply(func(m *RatUpperTriToeplitz) (*RatUpperTriToeplitz, error) {
+		return m.Pow(n)
+	})
+}
+
+/* elementary functions */
+
+// Exp, Sin and Cos are only rational at zero, Log at one, and Sqrt at the
+// square of a rational. other seeds return ErrNotRational.
+
+func (g *RatGDual) Exp() *RatGDual {
+	return g.apply((*RatUpperTriToeplitz).Exp)
+}
+
+func (g *RatGDual) Log() *RatGDual {
+	return g.apply((*RatUpperTriToeplitz).Log)
+}
+
+func (g *RatGDual) Sqrt() *RatGDual {
+	return g.apply((*RatUpperTriToeplitz).Sqrt)
+}
+
+func (g *RatGDual) Sin() *RatGDual {
+	return g.apply((*RatUpperTriToeplitz).Sin)
+}
+
+func (g *RatGDual) Cos() *RatGDual {
+	return g.apply((*RatUpperTriToeplitz).Cos)
+}
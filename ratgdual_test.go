@@ -0,0 +1,152 @@
+package gdual
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestRatFibonacci(t *testing.T) {
+	order := 100
+
+	// f(0.0) = 1 / (1 - x - x^2), the generating function of F_{k+1}
+	x := NewRatGDual(order, new(big.Rat), true)
+	one := NewRatGDual(order, big.NewRat(1, 1), false)
+	y := one.Div(one.Sub(x).Sub(x.Pow(2)))
+	if err := y.Err(); err != nil {
+		t.Fatalf("failed on fibonacci: %v", err)
+	}
+
+	a, b := big.NewInt(1), big.NewInt(1)
+	for k, coef := range y.Coefficients() {
+		if !coef.IsInt() || coef.Num().Cmp(a) != 0 {
+			t.Errorf("value mismatch on fibonacci (col %d): have %s want %s", k, coef.RatString(), a)
+		}
+		a, b = b, new(big.Int).Add(a, b)
+	}
+}
+
+func TestRatCatalan(t *testing.T) {
+	order := 30
+
+	// f(0.0) = (1 - sqrt(1 - 4x)) / 2x, the generating function of C_k
+	x := NewRatGDual(order, new(big.Rat), true)
+	one := NewRatGDual(order, big.NewRat(1, 1), false)
+	two := NewRatGDual(order, big.NewRat(2, 1), false)
+	four := NewRatGDual(order, big.NewRat(4, 1), false)
+	y := one.Sub(one.Sub(four.Mul(x)).Sqrt()).DivLimit(two.Mul(x))
+	if err := y.Err(); err != nil {
+		t.Fatalf("failed on catalan: %v", err)
+	}
+
+	if y.Order() != order-1 {
+		t.Fatalf("order mismatch on catalan: have %d want %d", y.Order(), order-1)
+	}
+
+	// C_k = (2k)! / ((k+1)! k!)
+	for k, coef := range y.Coefficients() {
+		expected := new(big.Int).Binomial(int64(2*k), int64(k))
+		expected.Quo(expected, big.NewInt(int64(k+1)))
+		if !coef.IsInt() || coef.Num().Cmp(expected) != 0 {
+			t.Errorf("value mismatch on catalan (col %d): have %s want %s", k, coef.RatString(), expected)
+		}
+	}
+}
+
+func TestRatBernoulli(t *testing.T) {
+	order := 16
+
+	// f(0.0) = x / (exp(x) - 1), where f^(k)(0) = B_k
+	x := NewRatGDual(order, new(big.Rat), true)
+	one := NewRatGDual(order, big.NewRat(1, 1), false)
+	y := x.DivLimit(x.Exp().Sub(one))
+	if err := y.Err(); err != nil {
+		t.Fatalf("failed on bernoulli: %v", err)
+	}
+
+	expected := map[int]*big.Rat{
+		0:  big.NewRat(1, 1),
+		1:  big.NewRat(-1, 2),
+		2:  big.NewRat(1, 6),
+		3:  new(big.Rat),
+		4:  big.NewRat(-1, 30),
+		6:  big.NewRat(1, 42),
+		8:  big.NewRat(-1, 30),
+		10: big.NewRat(5, 66),
+		12: big.NewRat(-691, 2730),
+		13: new(big.Rat),
+		14: big.NewRat(7, 6),
+	}
+
+	for k, want := range expected {
+		have, err := y.Derivative(k)
+		if err != nil {
+			t.Errorf("failed on bernoulli (col %d): %v", k, err)
+			continue
+		}
+
+		if have.Cmp(want) != 0 {
+			t.Errorf("value mismatch on bernoulli (col %d): have %s want %s", k, have.RatString(), want.RatString())
+		}
+	}
+}
+
+func TestRatPow(t *testing.T) {
+	order := 12
+
+	// f(2/3) = x^7 * x^-4 = x^3, with Miller's recurrence on both powers
+	x := NewRatGDual(order, big.NewRat(2, 3), true)
+	y := x.Pow(7).Mul(x.Pow(-4))
+	expected := []*big.Rat{big.NewRat(8, 27), big.NewRat(4, 3), big.NewRat(2, 1), big.NewRat(1, 1)}
+
+	for k, coef := range y.Coefficients() {
+		want := new(big.Rat)
+		if k < len(expected) {
+			want = expected[k]
+		}
+
+		if coef.Cmp(want) != 0 {
+			t.Errorf("value mismatch on pow (col %d): have %s want %s", k, coef.RatString(), want.RatString())
+		}
+	}
+}
+
+func TestRatErrors(t *testing.T) {
+	order := 5
+
+	x := NewRatGDual(order, big.NewRat(1, 1), true)
+	two := NewRatGDual(order, big.NewRat(2, 1), true)
+	zero := NewRatGDual(order, new(big.Rat), false)
+	negative := NewRatGDual(order, big.NewRat(-4, 1), true)
+
+	tests := []struct {
+		name     string
+		have     *RatGDual
+		expected error
+	}{
+		{"exp", x.Exp(), ErrNotRational},
+		{"sin", x.Sin(), ErrNotRational},
+		{"cos", x.Cos(), ErrNotRational},
+		{"log", two.Log(), ErrNotRational},
+		{"sqrt", two.Sqrt(), ErrNotRational},
+		{"log of negative", negative.Log(), ErrDomain},
+		{"sqrt of negative", negative.Sqrt(), ErrDomain},
+		{"div", x.Div(zero), ErrDivisionByZero},
+		{"inv", zero.Inv(), ErrDivisionByZero},
+		{"pow", zero.Pow(-1), ErrDivisionByZero},
+		{"propagated", x.Exp().Add(x).Mul(x), ErrNotRational},
+		{"propagated right", x.Add(zero.Inv()), ErrDivisionByZero},
+		{"none", x.Log().Add(negative.Mul(negative).Sqrt()).Pow(-3), nil},
+	}
+
+	for i, tt := range tests {
+		if !errors.Is(tt.have.Err(), tt.expected) {
+			t.Errorf("error mismatch on %s test %d: have %v want %v",
+				tt.name, i, tt.have.Err(), tt.expected)
+		}
+	}
+
+	if _, err := x.Coefficient(order); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("error mismatch on coefficient: have %v want %v", err, ErrIndexOutOfRange)
+	}
+}
@@ -0,0 +1,451 @@
+/*
+
+upper triangular Toeplitz matrices of big.Rat.
+
+the arithmetic of truncated power series only ever adds, multiplies
+and divides coefficients, so for a rational seed every coefficient of
+a rational function (including its powers and inverse) is a rational
+number, and big.Rat gives it exactly, with no rounding at all.
+
+the elementary functions mostly leave the rationals (exp(1) = e),
+but their recurrences stay exact once the value at the seed is
+rational. so they're only defined at the seeds where it is: Exp, Sin
+and Cos at zero, Log at one, and Sqrt at the square of a rational.
+everywhere else they return ErrNotRational.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type RatUpperTriToeplitz struct {
+	order int
+	val   []*big.Rat
+}
+
+func NewRatUpperTriToeplitz(order int) *RatUpperTriToeplitz {
+	val := make([]*big.Rat, order)
+	for i := range val {
+		val[i] = new(big.Rat)
+	}
+
+	mat := &RatUpperTriToeplitz{
+		order: order,
+		val:   val,
+	}
+
+	return mat
+}
+
+/* utility functions */
+
+// get returns the coefficient itself, which the caller shouldn't modify
+func (m *RatUpperTriToeplitz) get(i int) *big.Rat {
+	if i < 0 || i >= m.order {
+		return new(big.Rat)
+	}
+
+	return m.val[i]
+}
+
+func (m *RatUpperTriToeplitz) set(i int, val *big.Rat) {
+	if i < 0 || i >= m.order {
+		return
+	}
+
+	m.val[i].Set(val)
+}
+
+func (m *RatUpperTriToeplitz) Order() int {
+	return m.order
+}
+
+// Coefficient returns a copy of the i-th coefficient
+func (m *RatUpperTriToeplitz) Coefficient(i int) *big.Rat {
+	return new(big.Rat).Set(m.get(i))
+}
+
+func (m *RatUpperTriToeplitz) Fill(diagonal int, val *big.Rat) {
+	// fill the given upper diagonal of the matrix
+	m.set(diagonal, val)
+}
+
+func (m *RatUpperTriToeplitz) Reset(val *big.Rat) {
+	for i := 0; i < m.order; i++ {
+		m.set(i, val)
+	}
+}
+
+func (m *RatUpperTriToeplitz) Copy() *RatUpperTriToeplitz {
+	copy := NewRatUpperTriToeplitz(m.order)
+
+	for i := 0; i < m.order; i++ {
+		val := m.get(i)
+		copy.set(i, val)
+	}
+
+	return copy
+}
+
+/* element-wise matrix operations */
+
+func (m *RatUpperTriToeplitz) ElementAdd(val *big.Rat) {
+	for i := 0; i < m.order; i++ {
+		m.val[i].Add(m.val[i], val)
+	}
+}
+
+func (m *RatUpperTriToeplitz) ElementSub(val *big.Rat) {
+	for i := 0; i < m.order; i++ {
+		m.val[i].Sub(m.val[i], val)
+	}
+}
+
+func (m *RatUpperTriToeplitz) ElementMul(val *big.Rat) {
+	for i := 0; i < m.order; i++ {
+		m.val[i].Mul(m.val[i], val)
+	}
+}
+
+// big.Rat panics on division by zero, so ElementDiv returns an error instead
+func (m *RatUpperTriToeplitz) ElementDiv(val *big.Rat) error {
+	if val.Sign() == 0 {
+		return ErrDivisionByZero
+	}
+
+	for i := 0; i < m.order; i++ {
+		m.val[i].Quo(m.val[i], val)
+	}
+
+	return nil
+}
+
+/* matrix operations */
+
+// binary operations are truncated to the smaller order, as for the float64 matrix
+
+func (m *RatUpperTriToeplitz) Add(inp *RatUpperTriToeplitz) *RatUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewRatUpperTriToeplitz(order)
+	for i := 0; i < order; i++ {
+		out.val[i].Add(m.get(i), inp.get(i))
+	}
+
+	return out
+}
+
+func (m *RatUpperTriToeplitz) Sub(inp *RatUpperTriToeplitz) *RatUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewRatUpperTriToeplitz(order)
+	for i := 0; i < order; i++ {
+		out.val[i].Sub(m.get(i), inp.get(i))
+	}
+
+	return out
+}
+
+func (m *RatUpperTriToeplitz) Mul(inp *RatUpperTriToeplitz) *RatUpperTriToeplitz {
+	order := minOrder(m.order, inp.order)
+	out := NewRatUpperTriToeplitz(order)
+	product := new(big.Rat)
+	for i := 0; i < order; i++ {
+		for k := i; k >= 0; k-- {
+			product.Mul(m.get(i-k), inp.get(k))
+			out.val[i].Add(out.val[i], product)
+		}
+	}
+
+	return out
+}
+
+// b_0 = 1 / a_0, b_k = -(1 / a_0) * Σ a_j*b_{k-j}, see Inv in matrix.go
+func (m *RatUpperTriToeplitz) Inv() (*RatUpperTriToeplitz, error) {
+	inv := NewRatUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return inv, nil
+	}
+
+	a := m.get(0)
+	if a.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+
+	inv.val[0].Inv(a)
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(m, inv, k, 1, k, 0)
+		inv.val[k].Quo(sum.Neg(sum), a)
+	}
+
+	return inv, nil
+}
+
+func (m *RatUpperTriToeplitz) Div(inp *RatUpperTriToeplitz) (*RatUpperTriToeplitz, error) {
+	inv, err := inp.Inv()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Mul(inv), nil
+}
+
+// divides like Div, but resolves 0/0 by L'Hôpital's rule (see DivLimit in
+// matrix.go). the coefficients are exact, so only exact zeros are shifted.
+func (m *RatUpperTriToeplitz) DivLimit(inp *RatUpperTriToeplitz) (*RatUpperTriToeplitz, error) {
+	if err := checkOrder(m.order, inp.order); err != nil {
+		return nil, err
+	}
+
+	shift := 0
+	for shift < inp.order && inp.get(shift).Sign() == 0 {
+		shift++
+	}
+
+	if shift == inp.order {
+		return nil, ErrDivisionByZero
+	}
+
+	for i := 0; i < shift; i++ {
+		if m.get(i).Sign() != 0 {
+			return nil, ErrDivisionByZero
+		}
+	}
+
+	num := &RatUpperTriToeplitz{order: m.order - shift, val: m.val[shift:]}
+	den := &RatUpperTriToeplitz{order: inp.order - shift, val: inp.val[shift:]}
+
+	return num.Div(den)
+}
+
+// integer powers, with Miller's recurrence or by squaring (see Pow in matrix.go)
+func (m *RatUpperTriToeplitz) Pow(n int) (*RatUpperTriToeplitz, error) {
+	if n == 0 {
+		out := NewRatUpperTriToeplitz(m.order)
+		out.Fill(0, big.NewRat(1, 1))
+
+		return out, nil
+	}
+
+	if n < 0 {
+		inv, err := m.Inv()
+		if err != nil {
+			return nil, err
+		}
+
+		return inv.Pow(-n)
+	}
+
+	if m.order > 0 && m.get(0).Sign() != 0 {
+		return m.powMiller(n), nil
+	}
+
+	return m.powSquaring(n), nil
+}
+
+func (m *RatUpperTriToeplitz) powSquaring(n int) *RatUpperTriToeplitz {
+	out := NewRatUpperTriToeplitz(m.order)
+	out.Fill(0, big.NewRat(1, 1))
+
+	base := m
+	for n > 0 {
+		if n&1 == 1 {
+			out = out.Mul(base)
+		}
+
+		n >>= 1
+		if n > 0 {
+			base = base.Mul(base)
+		}
+	}
+
+	return out
+}
+
+// b_k = 1/(k*a_0) * Σ ((n+1)*j - k) * a_j * b_{k-j}, see PowReal in elementary.go
+func (m *RatUpperTriToeplitz) powMiller(n int) *RatUpperTriToeplitz {
+	out := NewRatUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return out
+	}
+
+	a := m.get(0)
+
+	// a_0^n, exactly
+	num := new(big.Int).Exp(a.Num(), big.NewInt(int64(n)), nil)
+	den := new(big.Int).Exp(a.Denom(), big.NewInt(int64(n)), nil)
+	out.val[0].SetFrac(num, den)
+
+	sum, factor, product := new(big.Rat), new(big.Rat), new(big.Rat)
+	for k := 1; k < m.order; k++ {
+		sum.SetInt64(0)
+		for j := 1; j <= k; j++ {
+			factor.SetInt64(int64((n+1)*j - k))
+			product.Mul(m.get(j), out.get(k-j))
+			sum.Add(sum, product.Mul(product, factor))
+		}
+		factor.SetInt64(int64(k))
+		out.val[k].Quo(sum, factor.Mul(factor, a))
+	}
+
+	return out
+}
+
+/* elementary functions */
+
+// Σ a_{j+off} * b_{k-j}, for j = from..to
+func (m *RatUpperTriToeplitz) convolve(a, b *RatUpperTriToeplitz, k, from, to, off int) *big.Rat {
+	sum, product := new(big.Rat), new(big.Rat)
+	for j := from; j <= to; j++ {
+		product.Mul(a.get(j+off), b.get(k-j))
+		sum.Add(sum, product)
+	}
+
+	return sum
+}
+
+// the derivative of the series, truncated to the same order
+func (m *RatUpperTriToeplitz) derivative() *RatUpperTriToeplitz {
+	out := NewRatUpperTriToeplitz(m.order)
+	for k := 1; k < m.order; k++ {
+		out.val[k-1].Mul(m.get(k), big.NewRat(int64(k), 1))
+	}
+
+	return out
+}
+
+func notRational(fn string, a *big.Rat) error {
+	return fmt.Errorf("%w: %s(%s)", ErrNotRational, fn, a.RatString())
+}
+
+// b' = b * a', only rational when a_0 = 0
+func (m *RatUpperTriToeplitz) Exp() (*RatUpperTriToeplitz, error) {
+	out := NewRatUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return out, nil
+	}
+
+	if a := m.get(0); a.Sign() != 0 {
+		return nil, notRational("exp", a)
+	}
+
+	da := m.derivative()
+	out.Fill(0, big.NewRat(1, 1))
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(da, out, k, 1, k, -1)
+		out.val[k].Quo(sum, big.NewRat(int64(k), 1))
+	}
+
+	return out, nil
+}
+
+// a * b' = a', only rational when a_0 = 1
+func (m *RatUpperTriToeplitz) Log() (*RatUpperTriToeplitz, error) {
+	out := NewRatUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return out, nil
+	}
+
+	a := m.get(0)
+	if a.Cmp(big.NewRat(1, 1)) != 0 {
+		if a.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: log(%s)", ErrDomain, a.RatString())
+		}
+
+		return nil, notRational("log", a)
+	}
+
+	db := NewRatUpperTriToeplitz(m.order)
+	for k := 1; k < m.order; k++ {
+		// b_k = (a_k - 1/k * Σ j*b_j*a_{k-j}) / a_0, where a_0 = 1
+		sum := m.convolve(db, m, k, 1, k-1, -1)
+		sum.Quo(sum, big.NewRat(int64(k), 1))
+		out.val[k].Sub(m.get(k), sum)
+		db.val[k-1].Mul(out.get(k), big.NewRat(int64(k), 1))
+	}
+
+	return out, nil
+}
+
+// the rational square root of a, if there is one
+func ratSqrt(a *big.Rat) (*big.Rat, bool) {
+	if a.Sign() < 0 {
+		return nil, false
+	}
+
+	num := new(big.Int).Sqrt(a.Num())
+	den := new(big.Int).Sqrt(a.Denom())
+
+	root := new(big.Rat).SetFrac(num, den)
+	square := new(big.Rat).Mul(root, root)
+
+	return root, square.Cmp(a) == 0
+}
+
+// b * b = a, only rational when a_0 is the square of a rational
+func (m *RatUpperTriToeplitz) Sqrt() (*RatUpperTriToeplitz, error) {
+	out := NewRatUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return out, nil
+	}
+
+	a := m.get(0)
+	if a.Sign() < 0 || (a.Sign() == 0 && m.order > 1) {
+		return nil, fmt.Errorf("%w: sqrt(%s)", ErrDomain, a.RatString())
+	}
+
+	b, ok := ratSqrt(a)
+	if !ok {
+		return nil, notRational("sqrt", a)
+	}
+
+	out.set(0, b)
+	twoB := new(big.Rat).Mul(b, big.NewRat(2, 1))
+	for k := 1; k < m.order; k++ {
+		sum := m.convolve(out, out, k, 1, k-1, 0)
+		sum.Sub(m.get(k), sum)
+		out.val[k].Quo(sum, twoB)
+	}
+
+	return out, nil
+}
+
+// s' = c * a' and c' = -s * a', only rational when a_0 = 0
+func (m *RatUpperTriToeplitz) sinCos(fn string) (*RatUpperTriToeplitz, *RatUpperTriToeplitz, error) {
+	sin := NewRatUpperTriToeplitz(m.order)
+	cos := NewRatUpperTriToeplitz(m.order)
+	if m.order == 0 {
+		return sin, cos, nil
+	}
+
+	if a := m.get(0); a.Sign() != 0 {
+		return nil, nil, notRational(fn, a)
+	}
+
+	da := m.derivative()
+	cos.Fill(0, big.NewRat(1, 1))
+	for k := 1; k < m.order; k++ {
+		fk := big.NewRat(int64(k), 1)
+
+		sumSin := m.convolve(da, cos, k, 1, k, -1)
+		sumCos := m.convolve(da, sin, k, 1, k, -1)
+		sin.val[k].Quo(sumSin, fk)
+		cos.val[k].Quo(sumCos.Neg(sumCos), fk)
+	}
+
+	return sin, cos, nil
+}
+
+func (m *RatUpperTriToeplitz) Sin() (*RatUpperTriToeplitz, error) {
+	sin, _, err := m.sinCos("sin")
+
+	return sin, err
+}
+
+func (m *RatUpperTriToeplitz) Cos() (*RatUpperTriToeplitz, error) {
+	_, cos, err := m.sinCos("cos")
+
+	return cos, err
+}
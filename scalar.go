@@ -0,0 +1,222 @@
+/*
+
+scalar arithmetic for the elements of generic matrices.
+
+the arithmetic operators work on any Field directly, but conversions
+and the math functions don't, since Go has no conversion from a float
+to a complex number. to get around it, every element passes through
+complex128, which can represent all of them: real types use the math
+package on the real part, and complex types use math/cmplx.
+
+the builtin types are handled with a type switch, and anything else
+(a user type defined as `type Meters float64`, for example) falls back
+to reflection on its underlying kind.
+
+Field is a closed set of types, rather than an interface of methods
+like Add and Mul that any type could implement. the recurrences are all
+written with Go's arithmetic operators, which only exist for the builtin
+types, and going through methods instead would put a call (and for
+big.Float, an allocation) behind every coefficient of every float64
+series. the element types that don't fit, since they need a precision,
+have to report errors or round outwards, get their own backends
+instead: BigGDual, RatGDual and IntervalGDual.
+
+*/
+
+package gdual
+
+import (
+	"math"
+	"math/cmplx"
+	"reflect"
+)
+
+// Field is the set of element types a generic matrix or GDual supports
+type Field interface {
+	~float32 | ~float64 | ~complex64 | ~complex128
+}
+
+func isComplex[T Field]() bool {
+	var zero T
+	switch any(zero).(type) {
+	case float32, float64:
+		return false
+	case complex64, complex128:
+		return true
+	}
+
+	kind := reflect.TypeOf(zero).Kind()
+
+	return kind == reflect.Complex64 || kind == reflect.Complex128
+}
+
+func toComplex[T Field](x T) complex128 {
+	switch v := any(x).(type) {
+	case float32:
+		return complex(float64(v), 0)
+	case float64:
+		return complex(v, 0)
+	case complex64:
+		return complex128(v)
+	case complex128:
+		return v
+	}
+
+	val := reflect.ValueOf(x)
+	if kind := val.Kind(); kind == reflect.Float32 || kind == reflect.Float64 {
+		return complex(val.Float(), 0)
+	}
+
+	return val.Complex()
+}
+
+// converts back to T, dropping the imaginary part for real types
+func fromComplex[T Field](c complex128) T {
+	var out T
+	switch p := any(&out).(type) {
+	case *float32:
+		*p = float32(real(c))
+	case *float64:
+		*p = real(c)
+	case *complex64:
+		*p = complex64(c)
+	case *complex128:
+		*p = c
+	default:
+		val := reflect.ValueOf(&out).Elem()
+		if kind := val.Kind(); kind == reflect.Float32 || kind == reflect.Float64 {
+			val.SetFloat(real(c))
+		} else {
+			val.SetComplex(c)
+		}
+	}
+
+	return out
+}
+
+func fromFloat[T Field](f float64) T {
+	return fromComplex[T](complex(f, 0))
+}
+
+func fromInt[T Field](n int) T {
+	return fromFloat[T](float64(n))
+}
+
+// the real part of x, which is all of it for real types
+func realPart[T Field](x T) float64 {
+	return real(toComplex(x))
+}
+
+func abs[T Field](x T) float64 {
+	return cmplx.Abs(toComplex(x))
+}
+
+// the smallest normal magnitude of T, below which it loses precision
+func smallestNormal[T Field]() float64 {
+	var zero T
+	if kind := reflect.TypeOf(zero).Kind(); kind == reflect.Float32 || kind == reflect.Complex64 {
+		return 0x1p-126
+	}
+
+	return 0x1p-1022
+}
+
+func isNaN[T Field](x T) bool {
+	return cmplx.IsNaN(toComplex(x))
+}
+
+func isInf[T Field](x T) bool {
+	return cmplx.IsInf(toComplex(x))
+}
+
+// applies fn to real elements and cfn to complex elements
+func lift[T Field](x T, fn func(float64) float64, cfn func(complex128) complex128) T {
+	c := toComplex(x)
+	if isComplex[T]() {
+		return fromComplex[T](cfn(c))
+	}
+
+	return fromFloat[T](fn(real(c)))
+}
+
+/* math functions */
+
+func scalarExp[T Field](x T) T {
+	return lift(x, math.Exp, cmplx.Exp)
+}
+
+func scalarLog[T Field](x T) T {
+	return lift(x, math.Log, cmplx.Log)
+}
+
+func scalarSqrt[T Field](x T) T {
+	return lift(x, math.Sqrt, cmplx.Sqrt)
+}
+
+func scalarSin[T Field](x T) T {
+	return lift(x, math.Sin, cmplx.Sin)
+}
+
+func scalarCos[T Field](x T) T {
+	return lift(x, math.Cos, cmplx.Cos)
+}
+
+func scalarTan[T Field](x T) T {
+	return lift(x, math.Tan, cmplx.Tan)
+}
+
+func scalarSinh[T Field](x T) T {
+	return lift(x, math.Sinh, cmplx.Sinh)
+}
+
+func scalarCosh[T Field](x T) T {
+	return lift(x, math.Cosh, cmplx.Cosh)
+}
+
+func scalarTanh[T Field](x T) T {
+	return lift(x, math.Tanh, cmplx.Tanh)
+}
+
+func scalarAsin[T Field](x T) T {
+	return lift(x, math.Asin, cmplx.Asin)
+}
+
+func scalarAcos[T Field](x T) T {
+	return lift(x, math.Acos, cmplx.Acos)
+}
+
+func scalarAtan[T Field](x T) T {
+	return lift(x, math.Atan, cmplx.Atan)
+}
+
+func scalarAsinh[T Field](x T) T {
+	return lift(x, math.Asinh, cmplx.Asinh)
+}
+
+func scalarAcosh[T Field](x T) T {
+	return lift(x, math.Acosh, cmplx.Acosh)
+}
+
+func scalarAtanh[T Field](x T) T {
+	return lift(x, math.Atanh, cmplx.Atanh)
+}
+
+func scalarPow[T Field](x, p T) T {
+	if isComplex[T]() {
+		return fromComplex[T](cmplx.Pow(toComplex(x), toComplex(p)))
+	}
+
+	return fromFloat[T](math.Pow(realPart(x), realPart(p)))
+}
+
+func scalarAtan2[T Field](y, x T) T {
+	if !isComplex[T]() {
+		return fromFloat[T](math.Atan2(realPart(y), realPart(x)))
+	}
+
+	// atan2(y, x) = -i * log((x + iy) / sqrt(x^2 + y^2))
+	cy, cx := toComplex(y), toComplex(x)
+	z := (cx + 1i*cy) / cmplx.Sqrt(cx*cx+cy*cy)
+
+	return fromComplex[T](-1i * cmplx.Log(z))
+}
@@ -0,0 +1,120 @@
+package gdual
+
+import (
+	"math"
+	"testing"
+)
+
+// user defined element types, which go through reflection
+type meters float64
+type phasor complex128
+
+func TestScalarConversions(t *testing.T) {
+	inp := complex(1.5, -0.25)
+
+	tests := []struct {
+		name     string
+		have     complex128
+		expected complex128
+	}{
+		{"float32", toComplex(fromComplex[float32](inp)), 1.5},
+		{"float64", toComplex(fromComplex[float64](inp)), 1.5},
+		{"complex64", toComplex(fromComplex[complex64](inp)), inp},
+		{"complex128", toComplex(fromComplex[complex128](inp)), inp},
+		{"meters", toComplex(fromComplex[meters](inp)), 1.5},
+		{"phasor", toComplex(fromComplex[phasor](inp)), inp},
+	}
+
+	for i, tt := range tests {
+		if tt.have != tt.expected {
+			t.Errorf("value mismatch on %s conversion test %d: have %v want %v",
+				tt.name, i, tt.have, tt.expected)
+		}
+	}
+
+	if isComplex[meters]() || !isComplex[phasor]() {
+		t.Errorf("failed to classify user defined types")
+	}
+}
+
+func TestGenericFloat32(t *testing.T) {
+	order := 8
+
+	// f(0.7) = exp(sin(x)) / (1 + x^2)
+	f32 := NewGDualOf(order, float32(0.7), true)
+	one32 := NewGDualOf(order, float32(1.0), false)
+	y32 := f32.Sin().Exp().Div(one32.Add(f32.Pow(2)))
+
+	f64 := NewGDual(order, 0.7, true)
+	one64 := NewGDual(order, 1.0, false)
+	y64 := f64.Sin().Exp().Div(one64.Add(f64.Pow(2)))
+
+	for i := 0; i < order; i++ {
+		have := float64(y32.mat.get(i))
+		expected := y64.mat.get(i)
+		if math.Abs(have-expected) > 1e-5*math.Max(1, math.Abs(expected)) {
+			t.Errorf("value mismatch on float32 test (col %d): have %f want %f",
+				i, have, expected)
+		}
+	}
+}
+
+/*
+the complex-step derivative: for a real function, f(x0 + ih) is
+f(x0) + ih*f'(x0) up to O(h^2), and since nothing is subtracted, h can
+be as small as we like. every coefficient of the complex GDual then
+carries the next coefficient of the real one in its imaginary part.
+*/
+func TestComplexStep(t *testing.T) {
+	order := 6
+	inp := 0.4
+	h := 1e-20
+
+	// f(0.4) = exp(x) * sin(x) / (1 + sqrt(x))
+	z := NewGDualOf(order, complex(inp, h), true)
+	one := NewGDualOf(order, complex(1.0, 0), false)
+	y := z.Exp().Mul(z.Sin()).Div(one.Add(z.Sqrt()))
+
+	x := NewGDual(order+1, inp, true)
+	one64 := NewGDual(order+1, 1.0, false)
+	expected := x.Exp().Mul(x.Sin()).Div(one64.Add(x.Sqrt()))
+
+	for k := 0; k < order; k++ {
+		have := imag(y.mat.get(k)) / h
+		want := float64(k+1) * expected.mat.get(k+1)
+		if !almostEqual(have, want) {
+			t.Errorf("value mismatch on complex step test (col %d): have %f want %f",
+				k, have, want)
+		}
+
+		if !almostEqual(real(y.mat.get(k)), expected.mat.get(k)) {
+			t.Errorf("value mismatch on complex step test (col %d): have %f want %f",
+				k, real(y.mat.get(k)), expected.mat.get(k))
+		}
+	}
+}
+
+func TestGenericUserType(t *testing.T) {
+	expected := []meters{
+		16.0, 32.0, 24.0, 8.0,
+	}
+
+	// f(2.0) = x^4, in meters
+	x := NewGDualOf(4, meters(2.0), true)
+	y := x.Pow(4)
+
+	for i := 0; i < len(expected); i++ {
+		if y.mat.get(i) != expected[i] {
+			t.Errorf("value mismatch on user type test (col %d): have %f want %f",
+				i, y.mat.get(i), expected[i])
+		}
+	}
+
+	derivs := x.Exp().Derivatives()
+	for i, deriv := range derivs {
+		if !almostEqual(float64(deriv), math.Exp(2.0)) {
+			t.Errorf("value mismatch on user type test (col %d): have %f want %f",
+				i, deriv, math.Exp(2.0))
+		}
+	}
+}
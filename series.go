@@ -0,0 +1,198 @@
+/*
+
+the interface between GDual and the matrices that store it.
+
+a GDual only needs the algebra of truncated power series: storage for
+the coefficients, and the arithmetic between them. both matrices in
+this library implement that algebra as the Series interface, the
+Toeplitz matrix as the default backend and the standard matrix as a
+reference to cross-check it against. any other backend (sparse,
+big-float, ...) can be plugged in the same way, by implementing Series
+and creating the dual number with NewGDualFrom.
+
+Series is generic over its element type, and over its own type so
+that the matrices can keep returning themselves from every operation.
+GDual is only generic over the element type, so it holds its matrix
+through the unexported series interface below, which erases the type
+of the backend.
+
+functions that a backend doesn't implement (all of the elementary
+functions, for example) are computed on a Toeplitz copy of the
+coefficients and converted back.
+
+*/
+
+package gdual
+
+type Series[T Field, S any] interface {
+	Order() int
+	Coefficient(i int) T
+	Fill(diagonal int, val T)
+	Reset(val T)
+	Copy() S
+
+	ElementAdd(val T)
+	ElementSub(val T)
+	ElementMul(val T)
+	ElementDiv(val T)
+
+	Add(inp S) S
+	Sub(inp S) S
+	Mul(inp S) S
+	Inv() S
+	Div(inp S) S
+	Pow(n int) S
+}
+
+// both matrices are backends for GDual
+var (
+	_ Series[float64, *UpperTriToeplitz] = (*UpperTriToeplitz)(nil)
+	_ Series[float64, *Matrix]           = (*Matrix)(nil)
+)
+
+type series[T Field] interface {
+	order() int
+	get(i int) T
+
+	add(inp series[T]) series[T]
+	sub(inp series[T]) series[T]
+	mul(inp series[T]) series[T]
+	inv() series[T]
+	div(inp series[T]) series[T]
+	pow(n int) series[T]
+
+	addScalar(c T) series[T]
+	mulScalar(c T) series[T]
+	divScalar(c T) series[T]
+
+	toeplitz() *UpperTriToeplitzOf[T]
+	from(mat *UpperTriToeplitzOf[T]) series[T]
+}
+
+// wraps a Series, along with the constructor for new matrices of its type
+type backend[T Field, S Series[T, S]] struct {
+	mat         S
+	constructor func(order int) S
+}
+
+func (b backend[T, S]) wrap(mat S) series[T] {
+	return backend[T, S]{
+		mat:         mat,
+		constructor: b.constructor,
+	}
+}
+
+// unwraps the input, converting it first if it comes from another backend
+func (b backend[T, S]) unwrap(inp series[T]) S {
+	if other, ok := inp.(backend[T, S]); ok {
+		return other.mat
+	}
+
+	return b.from(inp.toeplitz()).(backend[T, S]).mat
+}
+
+func (b backend[T, S]) order() int {
+	return b.mat.Order()
+}
+
+func (b backend[T, S]) get(i int) T {
+	return b.mat.Coefficient(i)
+}
+
+func (b backend[T, S]) add(inp series[T]) series[T] {
+	return b.wrap(b.mat.Add(b.unwrap(inp)))
+}
+
+func (b backend[T, S]) sub(inp series[T]) series[T] {
+	return b.wrap(b.mat.Sub(b.unwrap(inp)))
+}
+
+func (b backend[T, S]) mul(inp series[T]) series[T] {
+	return b.wrap(b.mat.Mul(b.unwrap(inp)))
+}
+
+func (b backend[T, S]) inv() series[T] {
+	return b.wrap(b.mat.Inv())
+}
+
+func (b backend[T, S]) div(inp series[T]) series[T] {
+	return b.wrap(b.mat.Div(b.unwrap(inp)))
+}
+
+func (b backend[T, S]) pow(n int) series[T] {
+	return b.wrap(b.mat.Pow(n))
+}
+
+// a scalar only adds to the value, unlike ElementAdd which adds it to every coefficient
+func (b backend[T, S]) addScalar(c T) series[T] {
+	out := b.mat.Copy()
+	if out.Order() > 0 {
+		out.Fill(0, out.Coefficient(0)+c)
+	}
+
+	return b.wrap(out)
+}
+
+func (b backend[T, S]) mulScalar(c T) series[T] {
+	out := b.mat.Copy()
+	out.ElementMul(c)
+
+	return b.wrap(out)
+}
+
+func (b backend[T, S]) divScalar(c T) series[T] {
+	out := b.mat.Copy()
+	out.ElementDiv(c)
+
+	return b.wrap(out)
+}
+
+func (b backend[T, S]) toeplitz() *UpperTriToeplitzOf[T] {
+	if mat, ok := any(b.mat).(*UpperTriToeplitzOf[T]); ok {
+		return mat
+	}
+
+	mat := NewUpperTriToeplitzOf[T](b.mat.Order())
+	for i := 0; i < mat.order; i++ {
+		mat.set(i, b.mat.Coefficient(i))
+	}
+
+	return mat
+}
+
+func (b backend[T, S]) from(mat *UpperTriToeplitzOf[T]) series[T] {
+	if out, ok := any(mat).(S); ok {
+		return b.wrap(out)
+	}
+
+	out := b.constructor(mat.order)
+	for i := 0; i < mat.order; i++ {
+		out.Fill(i, mat.get(i))
+	}
+
+	return b.wrap(out)
+}
+
+// applies a function of the Toeplitz matrix to any backend
+func apply[T Field](mat series[T], fn func(*UpperTriToeplitzOf[T]) *UpperTriToeplitzOf[T]) series[T] {
+	return mat.from(fn(mat.toeplitz()))
+}
+
+/* accessors required by Series */
+
+func (m *UpperTriToeplitzOf[T]) Order() int {
+	return m.order
+}
+
+func (m *UpperTriToeplitzOf[T]) Coefficient(i int) T {
+	return m.get(i)
+}
+
+func (m *Matrix) Order() int {
+	return m.order
+}
+
+// the coefficients of a Toeplitz matrix are its first row
+func (m *Matrix) Coefficient(i int) float64 {
+	return m.get(0, i)
+}
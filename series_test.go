@@ -0,0 +1,102 @@
+package gdual
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSeriesBackends(t *testing.T) {
+	order := 6
+
+	tests := []struct {
+		name string
+		fn   func(x *GDual) *GDual
+	}{
+		{
+			// f(x) = 4x^2 / (1 - x)^3
+			name: "rational",
+			fn: func(x *GDual) *GDual {
+				one := NewGDual(order, 1.0, false)
+				four := NewGDual(order, 4.0, false)
+				return x.Pow(2).Mul(four).Div(one.Sub(x).Pow(3))
+			},
+		},
+		{
+			name: "exp(sin(x))",
+			fn: func(x *GDual) *GDual {
+				return x.Sin().Exp()
+			},
+		},
+		{
+			name: "atan2(x, sqrt(x))",
+			fn: func(x *GDual) *GDual {
+				return x.Atan2(x.Sqrt())
+			},
+		},
+		{
+			name: "x^x / inv(x)",
+			fn: func(x *GDual) *GDual {
+				return x.PowGDual(x).Div(x.Inv())
+			},
+		},
+	}
+
+	// the standard matrix must agree with the Toeplitz matrix
+	for _, tt := range tests {
+		have := tt.fn(NewGDualFrom(NewMatrix, order, 3.0, true))
+		want := tt.fn(NewGDual(order, 3.0, true))
+
+		if _, ok := have.mat.(backend[float64, *Matrix]); !ok {
+			t.Errorf("backend mismatch on %s: have %T want %T",
+				tt.name, have.mat, backend[float64, *Matrix]{})
+		}
+
+		for n := 0; n < order; n++ {
+			if !almostEqual(have.mat.get(n), want.mat.get(n)) {
+				t.Errorf("value mismatch on %s (col %d): have %g want %g",
+					tt.name, n, have.mat.get(n), want.mat.get(n))
+			}
+		}
+	}
+}
+
+func TestSeriesBackendErrors(t *testing.T) {
+	x := NewGDualFrom(NewMatrix, 4, 0.0, true)
+	y := NewGDualFrom(NewMatrix, 3, 2.0, true)
+
+	if _, err := y.DivE(x); !errors.Is(err, ErrOrderMismatch) {
+		t.Errorf("expected order mismatch error: have %v", err)
+	}
+
+	if _, err := x.InvE(); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("expected division by zero error: have %v", err)
+	}
+
+	// sin(x) / x at x = 0 loses an order
+	z, err := x.Sin().DivLimit(x)
+	if err != nil {
+		t.Fatalf("unexpected error on limit: %v", err)
+	}
+
+	if z.Order() != 3 || z.Value() != 1.0 {
+		t.Errorf("value mismatch on limit: have %d, %g want %d, %g",
+			z.Order(), z.Value(), 3, 1.0)
+	}
+}
+
+func TestSeriesPow(t *testing.T) {
+	input := []float64{2, 1, 0.5, 0.25}
+
+	// Pow must agree between backends, including the identity and inverses
+	for _, n := range []int{-2, -1, 0, 1, 3} {
+		have := importMatrix(input).Pow(n)
+		want := importUpperTriToeplitz(input).Pow(n)
+
+		for k := 0; k < len(input); k++ {
+			if !almostEqual(have.Coefficient(k), want.Coefficient(k)) {
+				t.Errorf("value mismatch on exponent %d (col %d): have %g want %g",
+					n, k, have.Coefficient(k), want.Coefficient(k))
+			}
+		}
+	}
+}
@@ -0,0 +1,322 @@
+/*
+
+simplification of expression graphs.
+
+Simplify rebuilds the graph bottom up, and at every node:
+
+ - folds operations on constants into a single constant, as long as the
+   result is finite. NaN and infinite results are left to the evaluation,
+   and so are constants outside of the real domain of a function (like
+   log(-1)), which EvalOf can still evaluate with complex seeds.
+ - merges constants into a neighbouring sum or product, so (x*2)*3
+   becomes x*6. this can change the last bit of the result.
+ - removes identities: x+0, x-0, x*1, x/1, x^1 are x, and x^0 is 1.
+ - turns powers with a constant exponent into PowReal, or Pow when the
+   exponent is an integer, which skips the logarithm of PowExpr.
+ - merges nodes that compute the same operation on the same arguments,
+   so every distinct subexpression is evaluated once. sums and products
+   are commutative, so x*y and y*x are the same node.
+
+the original graph isn't modified.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"math"
+)
+
+// SimplifyStats reports the size of a graph before and after Simplify
+type SimplifyStats struct {
+	NodesBefore, NodesAfter int
+	OpsBefore, OpsAfter     int
+}
+
+func (s SimplifyStats) String() string {
+	return fmt.Sprintf("nodes %d -> %d, ops %d -> %d", s.NodesBefore, s.NodesAfter, s.OpsBefore, s.OpsAfter)
+}
+
+// OpCount returns the number of distinct operations in the graph, leaves excluded
+func (e *Expr) OpCount() int {
+	count := 0
+	for _, n := range e.nodes() {
+		if len(n.args) > 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+// OpCounts returns the number of distinct operations in the graph, by name
+func (e *Expr) OpCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, n := range e.nodes() {
+		if len(n.args) > 0 {
+			counts[opNames[n.op]]++
+		}
+	}
+
+	return counts
+}
+
+// Simplify returns a simplified copy of the graph, along with its size before and after
+func (e *Expr) Simplify() (*Expr, SimplifyStats) {
+	s := &simplifier{
+		nodes: make(map[exprKey]*Expr),
+		ids:   make(map[*Expr]int),
+	}
+
+	simplified := make(map[*Expr]*Expr)
+	for _, n := range e.nodes() {
+		args := make([]*Expr, len(n.args))
+		for i, arg := range n.args {
+			args[i] = simplified[arg]
+		}
+		simplified[n] = s.simplify(n, args)
+	}
+	out := simplified[e]
+
+	stats := SimplifyStats{
+		NodesBefore: e.NodeCount(),
+		NodesAfter:  out.NodeCount(),
+		OpsBefore:   e.OpCount(),
+		OpsAfter:    out.OpCount(),
+	}
+
+	return out, stats
+}
+
+// identifies a node by its operation and (already simplified) arguments
+type exprKey struct {
+	op     op
+	a, b   *Expr
+	symbol string
+	value  uint64
+	n      int
+}
+
+type simplifier struct {
+	nodes map[exprKey]*Expr
+	ids   map[*Expr]int // the order nodes were created in, to sort commutative arguments
+}
+
+// intern returns the existing node for n, or makes n the node for its key
+func (s *simplifier) intern(n *Expr) *Expr {
+	if (n.op == opAdd || n.op == opMul) && s.ids[n.args[0]] > s.ids[n.args[1]] {
+		n.args[0], n.args[1] = n.args[1], n.args[0]
+	}
+
+	key := exprKey{
+		op:     n.op,
+		symbol: n.symbol,
+		value:  math.Float64bits(n.value),
+		n:      n.n,
+	}
+	if len(n.args) > 0 {
+		key.a = n.args[0]
+	}
+	if len(n.args) > 1 {
+		key.b = n.args[1]
+	}
+
+	if node, ok := s.nodes[key]; ok {
+		return node
+	}
+
+	s.nodes[key] = n
+	s.ids[n] = len(s.ids)
+
+	return n
+}
+
+func (s *simplifier) constant(val float64) *Expr {
+	return s.intern(NewConstant(val))
+}
+
+func isConstant(e *Expr, val float64) bool {
+	return e.op == opConstant && e.value == val
+}
+
+// simplifies n, whose arguments have already been replaced by args
+func (s *simplifier) simplify(n *Expr, args []*Expr) *Expr {
+	out := &Expr{op: n.op, args: args, symbol: n.symbol, value: n.value, n: n.n}
+	if len(args) == 0 {
+		return s.intern(out)
+	}
+
+	if val, ok := fold(out); ok {
+		return s.constant(val)
+	}
+
+	var a, b *Expr
+	a = args[0]
+	if len(args) > 1 {
+		b = args[1]
+	}
+
+	switch n.op {
+	case opAdd:
+		if isConstant(a, 0) {
+			return b
+		}
+		if isConstant(b, 0) {
+			return a
+		}
+		return s.merge(out)
+	case opMul:
+		if isConstant(a, 1) {
+			return b
+		}
+		if isConstant(b, 1) {
+			return a
+		}
+		return s.merge(out)
+	case opSub:
+		if isConstant(b, 0) {
+			return a
+		}
+	case opDiv:
+		if isConstant(b, 1) {
+			return a
+		}
+	case opPow:
+		switch n.n {
+		case 0:
+			return s.constant(1)
+		case 1:
+			return a
+		}
+	case opPowReal:
+		switch n.value {
+		case 0:
+			return s.constant(1)
+		case 1:
+			return a
+		}
+	case opPowExpr:
+		if b.op == opConstant {
+			return s.power(a, b.value)
+		}
+	}
+
+	return s.intern(out)
+}
+
+// a^p, for a constant exponent p
+func (s *simplifier) power(a *Expr, p float64) *Expr {
+	pow := &Expr{op: opPowReal, args: []*Expr{a}, value: p}
+	if p == math.Trunc(p) && math.Abs(p) <= math.MaxInt32 {
+		pow = &Expr{op: opPow, args: []*Expr{a}, n: int(p)}
+	}
+
+	return s.simplify(pow, pow.args)
+}
+
+// merges a constant into a sum or product with a constant argument: (x+a)+b = x+(a+b)
+func (s *simplifier) merge(n *Expr) *Expr {
+	a, b := n.args[0], n.args[1]
+	if a.op == opConstant {
+		a, b = b, a
+	}
+
+	if b.op != opConstant || a.op != n.op {
+		return s.intern(n)
+	}
+
+	inner, c := a.args[0], a.args[1]
+	if inner.op == opConstant {
+		inner, c = c, inner
+	}
+
+	if c.op != opConstant {
+		return s.intern(n)
+	}
+
+	merged := &Expr{op: n.op, args: []*Expr{c, b}}
+	val, ok := fold(merged)
+	if !ok {
+		return s.intern(n)
+	}
+
+	out := &Expr{op: n.op, args: []*Expr{inner, s.constant(val)}}
+
+	return s.simplify(out, out.args)
+}
+
+// fold evaluates an operation on constants, if the result is finite
+func fold(n *Expr) (float64, bool) {
+	for _, arg := range n.args {
+		if arg.op != opConstant {
+			return 0, false
+		}
+	}
+
+	var a, b float64
+	a = n.args[0].value
+	if len(n.args) > 1 {
+		b = n.args[1].value
+	}
+
+	var val float64
+	switch n.op {
+	case opAdd:
+		val = a + b
+	case opSub:
+		val = a - b
+	case opMul:
+		val = a * b
+	case opDiv:
+		val = a / b
+	case opInv:
+		val = 1 / a
+	case opPow:
+		val = math.Pow(a, float64(n.n))
+	case opPowReal:
+		val = math.Pow(a, n.value)
+	case opPowExpr:
+		val = math.Pow(a, b)
+	case opExp:
+		val = math.Exp(a)
+	case opLog:
+		val = math.Log(a)
+	case opSqrt:
+		val = math.Sqrt(a)
+	case opSin:
+		val = math.Sin(a)
+	case opCos:
+		val = math.Cos(a)
+	case opTan:
+		val = math.Tan(a)
+	case opSinh:
+		val = math.Sinh(a)
+	case opCosh:
+		val = math.Cosh(a)
+	case opTanh:
+		val = math.Tanh(a)
+	case opAsin:
+		val = math.Asin(a)
+	case opAcos:
+		val = math.Acos(a)
+	case opAtan:
+		val = math.Atan(a)
+	case opAtan2:
+		val = math.Atan2(a, b)
+	case opAsinh:
+		val = math.Asinh(a)
+	case opAcosh:
+		val = math.Acosh(a)
+	case opAtanh:
+		val = math.Atanh(a)
+	default:
+		return 0, false
+	}
+
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return 0, false
+	}
+
+	return val, true
+}
@@ -0,0 +1,94 @@
+package gdual
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	order := 8
+	x := NewVariable("x")
+
+	tests := []struct {
+		name      string
+		expr      *Expr
+		opsBefore int
+		opsAfter  int
+		muls      int
+	}{
+		// 4x^2 / (1 - x)^3 from TestComplex, with every subexpression built again where it is used
+		{"complex", x.Mul(x).Mul(NewConstant(2).Mul(NewConstant(2))).Div(
+			NewConstant(1).Sub(x).Mul(NewConstant(1).Sub(x)).Mul(NewConstant(1).Sub(x))),
+			9, 6, 4},
+		{"identities", x.Add(NewConstant(0)).Mul(NewConstant(1)).Pow(1).Div(NewConstant(1)).Sub(NewConstant(0)).Sin(),
+			6, 1, 0},
+		{"folding", x.Mul(NewConstant(3).Exp().Log()).Add(NewConstant(2).Sqrt().Pow(2)),
+			6, 2, 1},
+		{"merged constants", x.Mul(NewConstant(2)).Mul(NewConstant(3)).Add(NewConstant(1)).Add(NewConstant(-1)),
+			4, 1, 1},
+		{"commutative", x.Sin().Mul(x.Cos()).Add(x.Cos().Mul(x.Sin())),
+			7, 4, 1},
+		{"constant exponent", x.PowExpr(NewConstant(2).Add(NewConstant(1))).Add(x.PowExpr(NewConstant(0.5))),
+			4, 3, 0},
+		{"zero power", x.Sin().Pow(0).Add(x),
+			3, 1, 0},
+	}
+
+	for i, tt := range tests {
+		simplified, stats := tt.expr.Simplify()
+		t.Logf("%s: %s", tt.name, stats)
+
+		if stats.OpsBefore != tt.opsBefore || stats.OpsAfter != tt.opsAfter {
+			t.Errorf("value mismatch on %s test %d: have %s want ops %d -> %d (%s)",
+				tt.name, i, stats, tt.opsBefore, tt.opsAfter, simplified)
+		}
+
+		if have := simplified.OpCounts()["*"]; have != tt.muls {
+			t.Errorf("value mismatch on %s test %d: have %d multiplications want %d (%s)",
+				tt.name, i, have, tt.muls, simplified)
+		}
+
+		for _, inp := range []float64{0.25, 3.0} {
+			seeds := map[string]float64{"x": inp}
+			have, err := simplified.Eval(order, seeds)
+			if err != nil {
+				t.Fatalf("failed on %s test %d: %v", tt.name, i, err)
+			}
+
+			expected, _ := tt.expr.Eval(order, seeds)
+			for k := 0; k < order; k++ {
+				if !almostEqual(have.mat.get(k), expected.mat.get(k)) {
+					t.Errorf("value mismatch on %s test %d (input %v, col %d): have %v want %v",
+						tt.name, i, inp, k, have.mat.get(k), expected.mat.get(k))
+				}
+			}
+		}
+	}
+}
+
+func TestSimplifyDomain(t *testing.T) {
+	x := NewVariable("x")
+
+	// constants outside of the real domain aren't folded
+	for _, expr := range []*Expr{
+		NewConstant(-1).Log().Mul(x),
+		NewConstant(2).Asin().Mul(x),
+		x.Div(NewConstant(0)),
+	} {
+		simplified, stats := expr.Simplify()
+		if stats.OpsAfter != stats.OpsBefore {
+			t.Errorf("value mismatch on %s: folded into %s", expr, simplified)
+		}
+	}
+
+	// log(-1) = iπ, which the complex evaluation still sees
+	expr, _ := NewConstant(-1).Log().Mul(NewVariable("x")).Simplify()
+	y, err := EvalOf(expr, 3, map[string]complex128{"x": 1})
+	if err != nil {
+		t.Fatalf("failed on complex domain: %v", err)
+	}
+
+	if have := y.Value(); math.Abs(imag(have)-math.Pi) > 1e-15 {
+		t.Errorf("value mismatch on complex domain: have %v want %v", have, complex(0, math.Pi))
+	}
+}
@@ -0,0 +1,442 @@
+/*
+
+reverse mode (adjoint) automatic differentiation.
+
+a GDual carries the derivative of every intermediate result with
+respect to its seed, so the gradient of a function of n variables takes
+n evaluations. reverse mode goes the other way: a Tape records every
+operation as it's evaluated, and then walks the record backwards from
+the result, accumulating the adjoint ∂f/∂v of every value v it passes.
+that gives the whole gradient in a single backward pass, no matter how
+many variables there are.
+
+the backward pass is written against the same operations as GDual, so
+it can also be run with GDual values instead of floats (forward over
+reverse). seeding the variables along a direction v gives the gradient
+along with its derivative in that direction, which is the Hessian
+vector product Hv, without ever forming the Hessian.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+	"math"
+)
+
+type tapeNode struct {
+	op    op
+	a, b  int     // the arguments, as indexes into the tape
+	value float64 // the value of a constant, or the exponent of PowReal
+	n     int     // the exponent of Pow
+}
+
+// Tape records the operations on its variables, in the order they're evaluated
+type Tape struct {
+	nodes []tapeNode
+	vals  []tapeFloat
+	vars  []int
+}
+
+// TapeVar is a value recorded on a Tape. values of different tapes can't
+// be mixed, and doing so panics, like indexing past the end of a slice.
+type TapeVar struct {
+	tape  *Tape
+	index int
+}
+
+func NewTape() *Tape {
+	return &Tape{}
+}
+
+func (t *Tape) push(node tapeNode, val tapeFloat) *TapeVar {
+	t.nodes = append(t.nodes, node)
+	t.vals = append(t.vals, val)
+
+	return &TapeVar{tape: t, index: len(t.nodes) - 1}
+}
+
+// Var creates a variable. the gradient has one entry per variable, in the order they were created.
+func (t *Tape) Var(val float64) *TapeVar {
+	v := t.push(tapeNode{op: opVariable, a: -1, b: -1}, tapeFloat(val))
+	t.vars = append(t.vars, v.index)
+
+	return v
+}
+
+// Const creates a constant, whose adjoint isn't needed
+func (t *Tape) Const(val float64) *TapeVar {
+	return t.push(tapeNode{op: opConstant, a: -1, b: -1, value: val}, tapeFloat(val))
+}
+
+// check panics when v was recorded on another tape, whose indexes mean nothing on t
+func (t *Tape) check(v *TapeVar) {
+	if v.tape != t {
+		panic("gdual: TapeVar used with a tape it wasn't recorded on")
+	}
+}
+
+// Len returns the number of values recorded on the tape
+func (t *Tape) Len() int {
+	return len(t.nodes)
+}
+
+func (v *TapeVar) Value() float64 {
+	return float64(v.tape.vals[v.index])
+}
+
+/* backward pass */
+
+// Gradient returns ∂out/∂x for every variable x of the tape
+func (t *Tape) Gradient(out *TapeVar) []float64 {
+	t.check(out)
+	adjoints := backward(t, t.vals, out.index, func(val float64) tapeFloat {
+		return tapeFloat(val)
+	})
+
+	grad := make([]float64, len(t.vars))
+	for i, index := range t.vars {
+		grad[i] = float64(adjoints[index])
+	}
+
+	return grad
+}
+
+// HessianVector returns the gradient of out, and the product of its Hessian with v
+func (t *Tape) HessianVector(out *TapeVar, v []float64) ([]float64, []float64, error) {
+	t.check(out)
+	if len(v) != len(t.vars) {
+		return nil, nil, fmt.Errorf("%w: %d directions for %d variables", ErrDimensionMismatch, len(v), len(t.vars))
+	}
+
+	order := 2
+	constant := func(val float64) *GDual {
+		return NewGDual(order, val, false)
+	}
+
+	// replay the tape with x + v*t, so each value knows its derivative along v
+	direction := make(map[int]float64, len(v))
+	for i, index := range t.vars {
+		direction[index] = v[i]
+	}
+
+	vals := replay(t, func(index int, node tapeNode) *GDual {
+		seed := constant(float64(t.vals[index]))
+		if node.op == opConstant {
+			return seed
+		}
+
+		return seed.Add(NewGDual(order, 0.0, true).Mul(constant(direction[index])))
+	})
+
+	adjoints := backward(t, vals, out.index, constant)
+
+	grad := make([]float64, len(t.vars))
+	hv := make([]float64, len(t.vars))
+	for i, index := range t.vars {
+		grad[i] = adjoints[index].mat.get(0)
+		hv[i] = adjoints[index].mat.get(1)
+	}
+
+	return grad, hv, nil
+}
+
+// evaluates the tape again, with leaf creating the variables and constants
+func replay[V algebra[V]](t *Tape, leaf func(index int, node tapeNode) V) []V {
+	vals := make([]V, len(t.nodes))
+	for i, node := range t.nodes {
+		if node.op == opVariable || node.op == opConstant {
+			vals[i] = leaf(i, node)
+			continue
+		}
+
+		var b V
+		if node.b >= 0 {
+			b = vals[node.b]
+		}
+		vals[i] = applyOp(node.op, node.n, node.value, vals[node.a], b)
+	}
+
+	return vals
+}
+
+/*
+backward accumulates the adjoint of every value the result depends on,
+walking the tape from out back to the start. each operation adds its
+adjoint, times its partial derivative, to the adjoints of its arguments.
+values out doesn't depend on keep a zero adjoint.
+*/
+func backward[V algebra[V]](t *Tape, vals []V, out int, constant func(float64) V) []V {
+	adjoints := make([]V, len(t.nodes))
+	seen := make([]bool, len(t.nodes))
+
+	accumulate := func(i int, val V) {
+		if seen[i] {
+			adjoints[i] = adjoints[i].Add(val)
+		} else {
+			adjoints[i] = val
+			seen[i] = true
+		}
+	}
+
+	one := constant(1)
+	accumulate(out, one)
+	for i := out; i >= 0; i-- {
+		if !seen[i] {
+			continue
+		}
+
+		node := t.nodes[i]
+		g, val := adjoints[i], vals[i]
+
+		var a, b V
+		if node.a >= 0 {
+			a = vals[node.a]
+		}
+		if node.b >= 0 {
+			b = vals[node.b]
+		}
+
+		switch node.op {
+		case opAdd:
+			accumulate(node.a, g)
+			accumulate(node.b, g)
+		case opSub:
+			accumulate(node.a, g)
+			accumulate(node.b, constant(0).Sub(g))
+		case opMul:
+			accumulate(node.a, g.Mul(b))
+			accumulate(node.b, g.Mul(a))
+		case opDiv:
+			// d(a/b) = da/b - (a/b) * db/b
+			accumulate(node.a, g.Div(b))
+			accumulate(node.b, constant(0).Sub(g.Mul(val).Div(b)))
+		case opInv:
+			accumulate(node.a, constant(0).Sub(g.Mul(val).Mul(val)))
+		case opPow:
+			// a^0 is constant, even where a^-1 isn't finite
+			if node.n != 0 {
+				accumulate(node.a, g.Mul(constant(float64(node.n))).Mul(a.Pow(node.n-1)))
+			}
+		case opPowReal:
+			if node.value != 0 {
+				accumulate(node.a, g.Mul(constant(node.value)).Mul(a.PowReal(node.value-1)))
+			}
+		case opPowExpr:
+			// d(a^b) = b * a^(b-1) * da + a^b * log(a) * db
+			accumulate(node.a, g.Mul(b).Mul(a.PowGDual(b.Sub(one))))
+			accumulate(node.b, g.Mul(val).Mul(a.Log()))
+		case opExp:
+			accumulate(node.a, g.Mul(val))
+		case opLog:
+			accumulate(node.a, g.Div(a))
+		case opSqrt:
+			accumulate(node.a, g.Div(val.Mul(constant(2))))
+		case opSin:
+			accumulate(node.a, g.Mul(a.Cos()))
+		case opCos:
+			accumulate(node.a, constant(0).Sub(g.Mul(a.Sin())))
+		case opTan:
+			accumulate(node.a, g.Mul(one.Add(val.Mul(val))))
+		case opSinh:
+			accumulate(node.a, g.Mul(a.Cosh()))
+		case opCosh:
+			accumulate(node.a, g.Mul(a.Sinh()))
+		case opTanh:
+			accumulate(node.a, g.Mul(one.Sub(val.Mul(val))))
+		case opAsin:
+			accumulate(node.a, g.Div(one.Sub(a.Mul(a)).Sqrt()))
+		case opAcos:
+			accumulate(node.a, constant(0).Sub(g.Div(one.Sub(a.Mul(a)).Sqrt())))
+		case opAtan:
+			accumulate(node.a, g.Div(one.Add(a.Mul(a))))
+		case opAtan2:
+			// atan2(a, b) = atan(a / b)  =>  (b*da - a*db) / (a^2 + b^2)
+			r := a.Mul(a).Add(b.Mul(b))
+			accumulate(node.a, g.Mul(b).Div(r))
+			accumulate(node.b, constant(0).Sub(g.Mul(a).Div(r)))
+		case opAsinh:
+			accumulate(node.a, g.Div(a.Mul(a).Add(one).Sqrt()))
+		case opAcosh:
+			accumulate(node.a, g.Div(a.Mul(a).Sub(one).Sqrt()))
+		case opAtanh:
+			accumulate(node.a, g.Div(one.Sub(a.Mul(a))))
+		}
+	}
+
+	for i := range adjoints {
+		if !seen[i] {
+			adjoints[i] = constant(0)
+		}
+	}
+
+	return adjoints
+}
+
+/* arithmetic */
+
+func (v *TapeVar) unary(op op, val tapeFloat) *TapeVar {
+	return v.tape.push(tapeNode{op: op, a: v.index, b: -1}, val)
+}
+
+func (v *TapeVar) binary(op op, inp *TapeVar, val tapeFloat) *TapeVar {
+	v.tape.check(inp)
+	return v.tape.push(tapeNode{op: op, a: v.index, b: inp.index}, val)
+}
+
+func (v *TapeVar) val() tapeFloat {
+	return v.tape.vals[v.index]
+}
+
+func (v *TapeVar) Add(inp *TapeVar) *TapeVar {
+	return v.binary(opAdd, inp, v.val().Add(inp.val()))
+}
+
+func (v *TapeVar) Sub(inp *TapeVar) *TapeVar {
+	return v.binary(opSub, inp, v.val().Sub(inp.val()))
+}
+
+func (v *TapeVar) Mul(inp *TapeVar) *TapeVar {
+	return v.binary(opMul, inp, v.val().Mul(inp.val()))
+}
+
+func (v *TapeVar) Div(inp *TapeVar) *TapeVar {
+	return v.binary(opDiv, inp, v.val().Div(inp.val()))
+}
+
+func (v *TapeVar) Inv() *TapeVar {
+	return v.unary(opInv, v.val().Inv())
+}
+
+func (v *TapeVar) Pow(n int) *TapeVar {
+	out := v.unary(opPow, v.val().Pow(n))
+	v.tape.nodes[out.index].n = n
+
+	return out
+}
+
+/* elementary functions */
+
+func (v *TapeVar) Exp() *TapeVar {
+	return v.unary(opExp, v.val().Exp())
+}
+
+func (v *TapeVar) Log() *TapeVar {
+	return v.unary(opLog, v.val().Log())
+}
+
+func (v *TapeVar) Sqrt() *TapeVar {
+	return v.unary(opSqrt, v.val().Sqrt())
+}
+
+func (v *TapeVar) Sin() *TapeVar {
+	return v.unary(opSin, v.val().Sin())
+}
+
+func (v *TapeVar) Cos() *TapeVar {
+	return v.unary(opCos, v.val().Cos())
+}
+
+func (v *TapeVar) Tan() *TapeVar {
+	return v.unary(opTan, v.val().Tan())
+}
+
+func (v *TapeVar) Sinh() *TapeVar {
+	return v.unary(opSinh, v.val().Sinh())
+}
+
+func (v *TapeVar) Cosh() *TapeVar {
+	return v.unary(opCosh, v.val().Cosh())
+}
+
+func (v *TapeVar) Tanh() *TapeVar {
+	return v.unary(opTanh, v.val().Tanh())
+}
+
+/* inverse functions */
+
+func (v *TapeVar) Asin() *TapeVar {
+	return v.unary(opAsin, v.val().Asin())
+}
+
+func (v *TapeVar) Acos() *TapeVar {
+	return v.unary(opAcos, v.val().Acos())
+}
+
+func (v *TapeVar) Atan() *TapeVar {
+	return v.unary(opAtan, v.val().Atan())
+}
+
+// Atan2 returns atan(v / inp), using the signs of both to pick the quadrant
+func (v *TapeVar) Atan2(inp *TapeVar) *TapeVar {
+	return v.binary(opAtan2, inp, v.val().Atan2(inp.val()))
+}
+
+func (v *TapeVar) Asinh() *TapeVar {
+	return v.unary(opAsinh, v.val().Asinh())
+}
+
+func (v *TapeVar) Acosh() *TapeVar {
+	return v.unary(opAcosh, v.val().Acosh())
+}
+
+func (v *TapeVar) Atanh() *TapeVar {
+	return v.unary(opAtanh, v.val().Atanh())
+}
+
+/* powers */
+
+func (v *TapeVar) PowReal(p float64) *TapeVar {
+	out := v.unary(opPowReal, v.val().PowReal(p))
+	v.tape.nodes[out.index].value = p
+
+	return out
+}
+
+func (v *TapeVar) PowVar(e *TapeVar) *TapeVar {
+	return v.binary(opPowExpr, e, v.val().PowGDual(e.val()))
+}
+
+/* floats */
+
+// tapeFloat gives float64 the operations of GDual, for the backward pass
+type tapeFloat float64
+
+func (a tapeFloat) Add(b tapeFloat) tapeFloat { return a + b }
+func (a tapeFloat) Sub(b tapeFloat) tapeFloat { return a - b }
+func (a tapeFloat) Mul(b tapeFloat) tapeFloat { return a * b }
+func (a tapeFloat) Div(b tapeFloat) tapeFloat { return a / b }
+func (a tapeFloat) Inv() tapeFloat            { return 1 / a }
+
+func (a tapeFloat) Pow(n int) tapeFloat {
+	return tapeFloat(math.Pow(float64(a), float64(n)))
+}
+
+func (a tapeFloat) PowReal(p float64) tapeFloat {
+	return tapeFloat(math.Pow(float64(a), p))
+}
+
+func (a tapeFloat) PowGDual(b tapeFloat) tapeFloat {
+	return tapeFloat(math.Pow(float64(a), float64(b)))
+}
+
+func (a tapeFloat) Exp() tapeFloat   { return tapeFloat(math.Exp(float64(a))) }
+func (a tapeFloat) Log() tapeFloat   { return tapeFloat(math.Log(float64(a))) }
+func (a tapeFloat) Sqrt() tapeFloat  { return tapeFloat(math.Sqrt(float64(a))) }
+func (a tapeFloat) Sin() tapeFloat   { return tapeFloat(math.Sin(float64(a))) }
+func (a tapeFloat) Cos() tapeFloat   { return tapeFloat(math.Cos(float64(a))) }
+func (a tapeFloat) Tan() tapeFloat   { return tapeFloat(math.Tan(float64(a))) }
+func (a tapeFloat) Sinh() tapeFloat  { return tapeFloat(math.Sinh(float64(a))) }
+func (a tapeFloat) Cosh() tapeFloat  { return tapeFloat(math.Cosh(float64(a))) }
+func (a tapeFloat) Tanh() tapeFloat  { return tapeFloat(math.Tanh(float64(a))) }
+func (a tapeFloat) Asin() tapeFloat  { return tapeFloat(math.Asin(float64(a))) }
+func (a tapeFloat) Acos() tapeFloat  { return tapeFloat(math.Acos(float64(a))) }
+func (a tapeFloat) Atan() tapeFloat  { return tapeFloat(math.Atan(float64(a))) }
+func (a tapeFloat) Asinh() tapeFloat { return tapeFloat(math.Asinh(float64(a))) }
+func (a tapeFloat) Acosh() tapeFloat { return tapeFloat(math.Acosh(float64(a))) }
+func (a tapeFloat) Atanh() tapeFloat { return tapeFloat(math.Atanh(float64(a))) }
+
+func (a tapeFloat) Atan2(b tapeFloat) tapeFloat {
+	return tapeFloat(math.Atan2(float64(a), float64(b)))
+}
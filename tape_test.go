@@ -0,0 +1,195 @@
+package gdual
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTapeGradient(t *testing.T) {
+	inputs := []float64{0.7, 1.3, 0.4}
+	symbols := []string{"x", "y", "z"}
+	v := []float64{0.5, -1.0, 2.0}
+
+	type tapeFn func(x, y, z *TapeVar, c func(float64) *TapeVar) *TapeVar
+	type multiFn func(x, y, z *MultiGDual, c func(float64) *MultiGDual) *MultiGDual
+
+	tests := []struct {
+		name  string
+		tape  tapeFn
+		multi multiFn
+	}{
+		{"arithmetic", func(x, y, z *TapeVar, c func(float64) *TapeVar) *TapeVar {
+			return x.Mul(y).Add(z.Pow(3).Div(x)).Sub(c(2).Mul(y.Inv())).Mul(x)
+		}, func(x, y, z *MultiGDual, c func(float64) *MultiGDual) *MultiGDual {
+			return x.Mul(y).Add(z.Pow(3).Div(x)).Sub(c(2).Mul(y.Inv())).Mul(x)
+		}},
+		{"elementary", func(x, y, z *TapeVar, c func(float64) *TapeVar) *TapeVar {
+			return x.Sin().Mul(y.Exp()).Add(z.Cos().Log().Mul(x.Sqrt())).Add(y.Tan().Mul(z.Tanh()))
+		}, func(x, y, z *MultiGDual, c func(float64) *MultiGDual) *MultiGDual {
+			return x.Sin().Mul(y.Exp()).Add(z.Cos().Log().Mul(x.Sqrt())).Add(y.Tan().Mul(z.Tanh()))
+		}},
+		{"hyperbolic", func(x, y, z *TapeVar, c func(float64) *TapeVar) *TapeVar {
+			return x.Sinh().Mul(z.Cosh()).Add(y.Asinh()).Add(y.Acosh().Mul(x))
+		}, func(x, y, z *MultiGDual, c func(float64) *MultiGDual) *MultiGDual {
+			return x.Sinh().Mul(z.Cosh()).Add(y.Asinh()).Add(y.Acosh().Mul(x))
+		}},
+		{"inverse", func(x, y, z *TapeVar, c func(float64) *TapeVar) *TapeVar {
+			return x.Asin().Mul(z.Acos()).Add(y.Atan().Mul(z.Atanh())).Add(x.Atan2(y.Add(z)))
+		}, func(x, y, z *MultiGDual, c func(float64) *MultiGDual) *MultiGDual {
+			return x.Asin().Mul(z.Acos()).Add(y.Atan().Mul(z.Atanh())).Add(x.Atan2(y.Add(z)))
+		}},
+		{"powers", func(x, y, z *TapeVar, c func(float64) *TapeVar) *TapeVar {
+			return x.PowVar(y).Add(z.PowReal(-1.5)).Add(y.Mul(z).Pow(0)).Mul(x.PowReal(2.5))
+		}, func(x, y, z *MultiGDual, c func(float64) *MultiGDual) *MultiGDual {
+			return x.PowGDual(y).Add(z.PowReal(-1.5)).Add(y.Mul(z).Pow(0)).Mul(x.PowReal(2.5))
+		}},
+		{"shared", func(x, y, z *TapeVar, c func(float64) *TapeVar) *TapeVar {
+			s := x.Mul(y).Sin()
+			return s.Mul(s).Add(s.Mul(z)).Sub(c(3))
+		}, func(x, y, z *MultiGDual, c func(float64) *MultiGDual) *MultiGDual {
+			s := x.Mul(y).Sin()
+			return s.Mul(s).Add(s.Mul(z)).Sub(c(3))
+		}},
+	}
+
+	for i, tt := range tests {
+		tape := NewTape()
+		x, y, z := tape.Var(inputs[0]), tape.Var(inputs[1]), tape.Var(inputs[2])
+		out := tt.tape(x, y, z, tape.Const)
+
+		// forward mode, with every first and second partial at once
+		order := 3
+		mx := NewMultiGDual(order, "x", inputs[0])
+		my := NewMultiGDual(order, "y", inputs[1])
+		mz := NewMultiGDual(order, "z", inputs[2])
+		expected := tt.multi(mx, my, mz, func(val float64) *MultiGDual {
+			return NewMultiConstant(order, val)
+		})
+
+		if !almostEqual(out.Value(), expected.Value()) {
+			t.Errorf("value mismatch on %s test %d: have %v want %v", tt.name, i, out.Value(), expected.Value())
+		}
+
+		grad := tape.Gradient(out)
+		hgrad, hv, err := tape.HessianVector(out, v)
+		if err != nil {
+			t.Fatalf("failed on %s test %d: %v", tt.name, i, err)
+		}
+
+		for j, s := range symbols {
+			partial := expected.Derivative(map[string]int{s: 1})
+			if !almostEqual(grad[j], partial) || !almostEqual(hgrad[j], partial) {
+				t.Errorf("value mismatch on %s test %d (∂%s): have %v and %v want %v",
+					tt.name, i, s, grad[j], hgrad[j], partial)
+			}
+
+			product := 0.0
+			for k, r := range symbols {
+				partials := map[string]int{s: 1}
+				partials[r]++
+				product += expected.Derivative(partials) * v[k]
+			}
+
+			if !almostEqual(hv[j], product) {
+				t.Errorf("value mismatch on %s test %d (Hv, %s): have %v want %v", tt.name, i, s, hv[j], product)
+			}
+		}
+	}
+}
+
+func TestTapeManyVariables(t *testing.T) {
+	n := 1000
+
+	// f(x) = Σ x_i^2 * x_{i+1}, in a single backward pass
+	tape := NewTape()
+	xs := make([]*TapeVar, n)
+	for i := range xs {
+		xs[i] = tape.Var(float64(i%7) / 7)
+	}
+
+	sum := tape.Const(0)
+	for i := 0; i+1 < n; i++ {
+		sum = sum.Add(xs[i].Pow(2).Mul(xs[i+1]))
+	}
+
+	grad := tape.Gradient(sum)
+	for i := range xs {
+		expected := 0.0
+		if i+1 < n {
+			expected += 2 * xs[i].Value() * xs[i+1].Value()
+		}
+		if i > 0 {
+			expected += xs[i-1].Value() * xs[i-1].Value()
+		}
+
+		if !almostEqual(grad[i], expected) {
+			t.Errorf("value mismatch on many variables (x_%d): have %v want %v", i, grad[i], expected)
+		}
+	}
+
+	// the forward mode gradient needs one pass per variable, so only check a few
+	for _, i := range []int{0, 1, n / 2, n - 1} {
+		seeds := make([]*GDual, n)
+		for j := range seeds {
+			seeds[j] = NewGDual(2, xs[j].Value(), i == j)
+		}
+
+		fsum := NewGDual(2, 0.0, false)
+		for j := 0; j+1 < n; j++ {
+			fsum = fsum.Add(seeds[j].Pow(2).Mul(seeds[j+1]))
+		}
+
+		if expected, _ := fsum.Derivative(1); !almostEqual(grad[i], expected) {
+			t.Errorf("value mismatch on forward gradient (x_%d): have %v want %v", i, grad[i], expected)
+		}
+	}
+}
+
+func TestTapeErrors(t *testing.T) {
+	tape := NewTape()
+	x, y := tape.Var(1.0), tape.Var(2.0)
+	out := x.Mul(y)
+
+	if _, _, err := tape.HessianVector(out, []float64{1.0}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("error mismatch on hessian vector: have %v want %v", err, ErrDimensionMismatch)
+	}
+
+	// a variable the result doesn't depend on has a zero gradient
+	z := tape.Var(3.0)
+	if grad := tape.Gradient(out); grad[2] != 0 || z.Value() != 3.0 {
+		t.Errorf("value mismatch on unused variable: have %v want %v", grad[2], 0.0)
+	}
+}
+
+func TestTapeMixed(t *testing.T) {
+	t1, t2 := NewTape(), NewTape()
+	x, y := t1.Var(2.0), t2.Var(3.0)
+	out := t1.Var(1.0).Mul(x)
+
+	// a value of one tape means nothing on another
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"binary", func() { x.Mul(y) }},
+		{"pow var", func() { y.PowVar(x) }},
+		{"gradient", func() { t2.Gradient(out) }},
+		{"hessian vector", func() { t2.HessianVector(out, []float64{1.0}) }},
+	}
+
+	for i, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic on mixed %s test %d", tt.name, i)
+				}
+			}()
+			tt.fn()
+		}()
+	}
+
+	// and the tapes are still usable after
+	if grad := t1.Gradient(out); len(grad) != 2 || grad[0] != 1.0 || grad[1] != 2.0 {
+		t.Errorf("value mismatch on mixed tapes: have %v want %v", grad, []float64{1.0, 2.0})
+	}
+}
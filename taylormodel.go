@@ -0,0 +1,139 @@
+/*
+
+Taylor models, for rigorous bounds on the range of a function.
+
+a Taylor model of order n for f over the box [c - r, c + r] is the
+Taylor polynomial of f at c, of degree n-1, together with an interval
+that bounds the remainder of the polynomial anywhere in the box. by
+Taylor's theorem (with the Lagrange form of the remainder),
+
+	f(c + h) = Σ a_k * h^k + f^(n)(ξ) / n! * h^n,  for k < n
+
+for some ξ between c and c + h. the coefficients a_k are enclosed by an
+IntervalGDual seeded at the point c, and f^(n)(ξ) / n! by another seeded
+with the whole box, which encloses that coefficient for every ξ at once.
+so the function is evaluated twice, which is why it's passed in as a
+function of its variable rather than as an IntervalGDual.
+
+*/
+
+package gdual
+
+import (
+	"fmt"
+)
+
+type TaylorModel struct {
+	center float64
+	box    Interval // the box, rounded outward
+	offset Interval // every h with c + h in the box
+
+	coefs     []Interval
+	remainder Interval // the remainder over the whole box
+	last      Interval // the enclosure of f^(n)(ξ) / n!
+}
+
+/*
+NewTaylorModel builds the Taylor model of order n for fn over [center - radius,
+center + radius]. fn is called with the variable of an IntervalGDual, and
+constants should be created with the same order as it:
+
+	tm, err := NewTaylorModel(5, 0.5, 0.1, func(x *IntervalGDual) *IntervalGDual {
+		one := NewIntervalGDual(x.Order(), Point(1), false)
+		return one.Add(x.Pow(2)).Inv()
+	})
+
+any error in fn, over either the center or the box, is returned instead.
+*/
+func NewTaylorModel(order int, center, radius float64, fn func(x *IntervalGDual) *IntervalGDual) (*TaylorModel, error) {
+	if order < 1 || radius < 0 {
+		return nil, fmt.Errorf("%w: taylor model of order %d and radius %v", ErrDomain, order, radius)
+	}
+
+	box := Point(center).Add(NewInterval(-radius, radius))
+	offset := box.Sub(Point(center))
+
+	poly := fn(NewIntervalGDual(order, Point(center), true))
+	if err := poly.Err(); err != nil {
+		return nil, err
+	}
+
+	bound := fn(NewIntervalGDual(order+1, box, true))
+	if err := bound.Err(); err != nil {
+		return nil, err
+	}
+
+	last, err := bound.Coefficient(order)
+	if err != nil {
+		return nil, err
+	}
+
+	tm := &TaylorModel{
+		center:    center,
+		box:       box,
+		offset:    offset,
+		coefs:     poly.Coefficients(),
+		remainder: last.Mul(offset.pow(order)),
+		last:      last,
+	}
+
+	return tm, nil
+}
+
+/* accessors */
+
+func (t *TaylorModel) Order() int {
+	return len(t.coefs)
+}
+
+func (t *TaylorModel) Center() float64 {
+	return t.center
+}
+
+// Box returns the domain of the model, which is slightly wider than
+// [center - radius, center + radius] after rounding
+func (t *TaylorModel) Box() Interval {
+	return t.box
+}
+
+// Coefficients returns an enclosure of the coefficients of the polynomial
+func (t *TaylorModel) Coefficients() []Interval {
+	coefs := make([]Interval, len(t.coefs))
+	copy(coefs, t.coefs)
+
+	return coefs
+}
+
+// Remainder returns a bound on f minus the polynomial, over the whole box
+func (t *TaylorModel) Remainder() Interval {
+	return t.remainder
+}
+
+/* bounds */
+
+// evaluates the polynomial at every point of h, with Horner's method
+func (t *TaylorModel) polynomial(h Interval) Interval {
+	var sum Interval
+	for k := len(t.coefs) - 1; k >= 0; k-- {
+		sum = sum.Mul(h).Add(t.coefs[k])
+	}
+
+	return sum
+}
+
+// Bound returns an enclosure of the range of f over the box
+func (t *TaylorModel) Bound() Interval {
+	return t.polynomial(t.offset).Add(t.remainder)
+}
+
+// Eval returns an enclosure of f(x), for x in the box
+func (t *TaylorModel) Eval(x float64) (Interval, error) {
+	if !t.box.Contains(x) {
+		return Interval{}, fmt.Errorf("%w: %v outside of the box %v", ErrDomain, x, t.box)
+	}
+
+	h := Point(x).Sub(Point(t.center))
+	remainder := t.last.Mul(h.pow(len(t.coefs)))
+
+	return t.polynomial(h).Add(remainder), nil
+}
@@ -0,0 +1,132 @@
+/*
+
+workspaces, for reusing dual numbers.
+
+the destination operations (see destination.go) stop a GDual from
+allocating once it has grown, but every GDual still owns its matrix,
+and the scratch matrices its operations need. a service that evaluates
+millions of short-lived dual numbers would rather hand those back when
+it's done with them. a Workspace keeps them instead:
+
+	w := NewWorkspace()
+	for ... {
+		x := w.New(5, 2.0, true)
+		y := w.New(5, 0.0, false).SetSin(x)
+
+		// ...
+
+		w.Release(x, y)
+	}
+
+a GDual from a workspace draws its scratch matrices from it too, and so
+do the results of operations on it, so everything they hold goes back
+to the workspace with Release. released dual numbers are kept by order
+and handed out again by New, so once the workspace has seen every order
+it's asked for, the loop above doesn't allocate at all.
+
+a GDual must not be used after it's released. releasing one twice, or
+one that didn't come from the workspace, does nothing. a workspace isn't
+safe for concurrent use, so each goroutine should have its own.
+
+*/
+
+package gdual
+
+type WorkspaceOf[T Field] struct {
+	gduals map[int][]*GDualOf[T]            // released dual numbers, by order
+	mats   map[int][]*UpperTriToeplitzOf[T] // released matrices, by order
+}
+
+// Workspace is a workspace for GDual, of float64
+type Workspace = WorkspaceOf[float64]
+
+// NewWorkspace creates a workspace for GDual
+func NewWorkspace() *Workspace {
+	return NewWorkspaceOf[float64]()
+}
+
+// NewWorkspaceOf creates a workspace for GDualOf[T]
+func NewWorkspaceOf[T Field]() *WorkspaceOf[T] {
+	w := &WorkspaceOf[T]{
+		gduals: make(map[int][]*GDualOf[T]),
+		mats:   make(map[int][]*UpperTriToeplitzOf[T]),
+	}
+
+	return w
+}
+
+// New returns a GDual like NewGDual, reusing a released one of the same order
+func (w *WorkspaceOf[T]) New(order int, seed T, variable bool) *GDualOf[T] {
+	var gdual *GDualOf[T]
+	if free := w.gduals[order]; len(free) > 0 {
+		gdual = free[len(free)-1]
+		w.gduals[order] = free[:len(free)-1]
+
+		mat, _ := toeplitzOf(gdual.mat)
+		mat.ws = w
+		mat.Reset(0)
+	} else {
+		gdual = &GDualOf[T]{
+			mat: backend[T, *UpperTriToeplitzOf[T]]{
+				mat:         w.matrix(order),
+				constructor: NewUpperTriToeplitzOf[T],
+			},
+		}
+	}
+
+	gdual.mat.toeplitz().Fill(0, seed)
+	if variable {
+		gdual.mat.toeplitz().Fill(1, 1.0)
+	}
+	gdual.variable = variable
+	gdual.symbols = nil
+	gdual.ctx = nil
+
+	return gdual
+}
+
+// Release hands dual numbers from the workspace back to it, along with their scratch matrices
+func (w *WorkspaceOf[T]) Release(gduals ...*GDualOf[T]) {
+	for _, gdual := range gduals {
+		if gdual == nil {
+			continue
+		}
+
+		mat, ok := toeplitzOf(gdual.mat)
+		if !ok || mat.ws != w {
+			continue
+		}
+
+		w.releaseTemps(mat)
+		mat.ws = nil
+		w.gduals[mat.order] = append(w.gduals[mat.order], gdual)
+	}
+}
+
+// matrix returns a zeroed matrix of the given order, which belongs to the workspace
+func (w *WorkspaceOf[T]) matrix(order int) *UpperTriToeplitzOf[T] {
+	var mat *UpperTriToeplitzOf[T]
+	if free := w.mats[order]; len(free) > 0 {
+		mat = free[len(free)-1]
+		w.mats[order] = free[:len(free)-1]
+	} else {
+		mat = new(UpperTriToeplitzOf[T])
+	}
+
+	mat.ws = w
+	mat.resize(order)
+	mat.Reset(0)
+
+	return mat
+}
+
+// releaseTemps hands the scratch matrices of mat back to the workspace
+func (w *WorkspaceOf[T]) releaseTemps(mat *UpperTriToeplitzOf[T]) {
+	for i, tmp := range mat.tmp {
+		w.releaseTemps(tmp)
+		tmp.ws = nil
+		w.mats[tmp.order] = append(w.mats[tmp.order], tmp)
+		mat.tmp[i] = nil
+	}
+	mat.tmp = mat.tmp[:0]
+}
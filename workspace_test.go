@@ -0,0 +1,116 @@
+package gdual
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWorkspace(t *testing.T) {
+	order := 8
+	w := NewWorkspace()
+
+	x := w.New(order, 0.7, true)
+	want := NewGDual(order, 0.7, true)
+
+	for k := 0; k < order; k++ {
+		if x.mat.get(k) != want.mat.get(k) {
+			t.Errorf("value mismatch on workspace seed (col %d): have %v want %v", k, x.mat.get(k), want.mat.get(k))
+		}
+	}
+
+	// the destination and allocating operations both work on workspace values
+	y := w.New(order, 0.0, false).SetSin(x)
+	y.SetDiv(y, w.New(order, 2.0, false).SetExp(x))
+	z := x.Sin().Div(x.Exp()).Atan()
+	expected := want.Sin().Div(want.Exp()).Atan()
+	y.SetAtan(y)
+
+	for k := 0; k < order; k++ {
+		if y.mat.get(k) != expected.mat.get(k) || z.mat.get(k) != expected.mat.get(k) {
+			t.Errorf("value mismatch on workspace (col %d): have %v and %v want %v",
+				k, y.mat.get(k), z.mat.get(k), expected.mat.get(k))
+		}
+	}
+
+	// released values are handed out again, zeroed and seeded
+	w.Release(y, z)
+	if again := w.New(order, 1.5, false); again != z || again.Value() != 1.5 || again.IsVariable() {
+		t.Errorf("value mismatch on reused workspace value: have %v", again.Coefficients())
+	} else if again.mat.get(1) != 0 {
+		t.Errorf("value mismatch on reused workspace value (col 1): have %v want %v", again.mat.get(1), 0.0)
+	}
+
+	if again := w.New(order, 0.0, false); again != y {
+		t.Errorf("expected the released value back from the workspace")
+	}
+
+	// releasing twice, or something from elsewhere, does nothing
+	w.Release(x, x, want, nil)
+	if len(w.gduals[order]) != 1 {
+		t.Errorf("length mismatch on released values: have %d want %d", len(w.gduals[order]), 1)
+	}
+
+	// and values of another order are kept apart
+	if other := w.New(order+1, 0.0, false); other == x || other.Order() != order+1 {
+		t.Errorf("order mismatch on workspace: have %d want %d", other.Order(), order+1)
+	}
+}
+
+func TestWorkspaceAllocs(t *testing.T) {
+	order := 10
+	w := NewWorkspace()
+
+	// f(3.0) = 4x^2 / (1 - x)^3 + asin(x / 4), as a service would evaluate it
+	eval := func(seed float64) float64 {
+		x := w.New(order, seed, true)
+		one := w.New(order, 1.0, false)
+		four := w.New(order, 4.0, false)
+		y := w.New(order, 0.0, false)
+		z := w.New(order, 0.0, false)
+
+		y.SetPow(x, 2).SetMul(y, four)
+		z.SetSub(one, x).SetPow(z, 3)
+		y.SetDiv(y, z)
+		z.SetDiv(x, four).SetAsin(z)
+		y.SetAdd(y, z)
+
+		value, _ := y.Derivative(order - 1)
+		w.Release(x, one, four, y, z)
+
+		return value
+	}
+
+	expected := eval(3.0)
+	allocs := testing.AllocsPerRun(100, func() {
+		if have := eval(3.0); have != expected {
+			t.Errorf("value mismatch on workspace evaluation: have %v want %v", have, expected)
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("allocation mismatch on workspace: have %v want 0", allocs)
+	}
+}
+
+func TestWorkspaceRevertError(t *testing.T) {
+	w := NewWorkspace()
+
+	// a constant has no inverse function, and Revert fails after taking
+	// its result and a scratch matrix from the workspace
+	x := w.New(5, 2.0, false)
+
+	for i := 0; i < 3; i++ {
+		if _, err := x.Revert(); !errors.Is(err, ErrNotInvertible) {
+			t.Fatalf("error mismatch on revert: have %v want %v", err, ErrNotInvertible)
+		}
+
+		pooled := 0
+		for _, mats := range w.mats {
+			pooled += len(mats)
+		}
+
+		if pooled != 2 {
+			t.Errorf("matrices mismatch on failed revert %d: have %d pooled want %d", i, pooled, 2)
+		}
+	}
+}